@@ -16,7 +16,29 @@ const (
 	terminalFormatTmpl = "{{ range .Recommendations }}{{.Rule}}\t[{{colored .Severity}}]   \t{{.Message}}\n{{ end }}"
 )
 
+var auditSeverities = []string{audit.SeverityLow, audit.SeverityMedium, audit.SeverityHigh, audit.SeverityCritical}
+
 func auditFunc(cmd *cobra.Command, _ []string) {
+	if auditOutput != formatText && auditOutput != formatJSON {
+		cmd.Println(errorMsg("ERROR unknown output format:") + fmt.Sprintf("\t%s. Supported formats: %s, %s\n", auditOutput, formatText, formatJSON))
+		os.Exit(ExitCodeUsage) // skipcq: RVV-A0003
+		return
+	}
+
+	if auditListRules {
+		if err := listAuditRules(cmd); err != nil {
+			cmd.Println(errorMsg("ERROR listing the rules:") + fmt.Sprintf("\t%s\n", err.Error()))
+			os.Exit(1) // skipcq: RVV-A0003
+		}
+		return
+	}
+
+	if auditSeverityGate != "" && auditSeverityRank(auditSeverityGate) < 0 {
+		cmd.Println(errorMsg("ERROR unknown severity gate:") + fmt.Sprintf("\t%s. Supported severities: %s\n", auditSeverityGate, strings.Join(auditSeverities, ", ")))
+		os.Exit(1) // skipcq: RVV-A0003
+		return
+	}
+
 	if cfgFile == "" {
 		cmd.Println(errorMsg("Please, provide the path to the configuration file with --config or see all the options with --help"))
 		os.Exit(1) // skipcq: RVV-A0003
@@ -32,7 +54,7 @@ func auditFunc(cmd *cobra.Command, _ []string) {
 	cfg.Normalize()
 
 	if formatTmpl == "" {
-		if IsTTY {
+		if UseColors() {
 			formatTmpl = terminalFormatTmpl
 		} else {
 			formatTmpl = defaultFormatTmpl
@@ -41,6 +63,7 @@ func auditFunc(cmd *cobra.Command, _ []string) {
 
 	severitiesToInclude = strings.ReplaceAll(severitiesToInclude, " ", "")
 	rules := strings.Split(strings.ReplaceAll(rulesToExclude, " ", ""), ",")
+	rules = append(rules, splitRuleList(auditDisabledRules)...)
 
 	if rulesToExcludePath != "" {
 		b, err := os.ReadFile(rulesToExcludePath)
@@ -67,6 +90,21 @@ func auditFunc(cmd *cobra.Command, _ []string) {
 		os.Exit(1) // skipcq: RVV-A0003
 		return
 	}
+	result.Recommendations = enabledRecommendations(result.Recommendations, splitRuleList(auditEnabledRules))
+	failed := len(gatedRecommendations(result.Recommendations, auditSeverityGate)) > 0
+
+	if auditOutput == formatJSON {
+		enc := newJSONEncoder(cmd.OutOrStdout())
+		if err := enc.Encode(result); err != nil {
+			cmd.Println(errorMsg("ERROR rendering the results:") + fmt.Sprintf("\t%s\n", err.Error()))
+			os.Exit(1) // skipcq: RVV-A0003
+			return
+		}
+		if failed {
+			os.Exit(1) // skipcq: RVV-A0003
+		}
+		return
+	}
 
 	funcMap := template.FuncMap{
 		"marshal": func(v interface{}) string {
@@ -102,7 +140,72 @@ func auditFunc(cmd *cobra.Command, _ []string) {
 		return
 	}
 
-	if len(result.Recommendations) > 0 {
+	if failed {
 		os.Exit(1) // skipcq: RVV-A0003
 	}
 }
+
+func listAuditRules(cmd *cobra.Command) error {
+	if auditOutput == formatJSON {
+		enc := newJSONEncoder(cmd.OutOrStdout())
+		return enc.Encode(auditRules)
+	}
+	for _, r := range auditRules {
+		cmd.Printf("%s\t[%s]\t%s\n", r.Rule, r.Severity, r.Message)
+	}
+	return nil
+}
+
+func splitRuleList(list string) []string {
+	var rules []string
+	for _, r := range strings.Split(strings.ReplaceAll(list, " ", ""), ",") {
+		if r != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// enabledRecommendations keeps the recommendations of the enabled rules. All the rules are
+// enabled when the list is empty
+func enabledRecommendations(recs []audit.Recommendation, enabled []string) []audit.Recommendation {
+	if len(enabled) == 0 {
+		return recs
+	}
+	res := []audit.Recommendation{}
+	for _, r := range recs {
+		for _, e := range enabled {
+			if r.Rule == e {
+				res = append(res, r)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// gatedRecommendations returns the recommendations at or above the severity gate. All of
+// them are returned when the gate is empty
+func gatedRecommendations(recs []audit.Recommendation, gate string) []audit.Recommendation {
+	if gate == "" {
+		return recs
+	}
+	min := auditSeverityRank(gate)
+	var res []audit.Recommendation
+	for _, r := range recs {
+		if auditSeverityRank(r.Severity) >= min {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// auditSeverityRank returns the position of the severity in ascending order, or -1 if unknown
+func auditSeverityRank(severity string) int {
+	for i, s := range auditSeverities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import audit "github.com/krakendio/krakend-audit"
+
+// auditRules mirrors the rule set of krakend-audit, which is not exported, so the rules can be
+// listed. Keep it in sync when upgrading the dependency
+var auditRules = []audit.Recommendation{
+	{Rule: "1.1.1", Severity: audit.SeverityHigh, Message: "Implement more secure alternatives than Basic Auth to protect your data."},
+	{Rule: "1.1.2", Severity: audit.SeverityMedium, Message: "Implement stateless authorization methods such as JWT to secure your endpoints as opposed to using API keys."},
+	{Rule: "1.2.1", Severity: audit.SeverityHigh, Message: "Prioritize using JWT for endpoint authorization to ensure security."},
+	{Rule: "2.1.1", Severity: audit.SeverityHigh, Message: "Only allow secure connections (avoid insecure_connections)."},
+	{Rule: "2.1.2", Severity: audit.SeverityHigh, Message: "Enable TLS or use a terminator in front of KrakenD."},
+	{Rule: "2.1.3", Severity: audit.SeverityCritical, Message: "TLS is configured but its disable flag prevents from using it."},
+	{Rule: "2.1.7", Severity: audit.SeverityHigh, Message: "Enable HTTP security header checks (security/http)."},
+	{Rule: "2.1.8", Severity: audit.SeverityHigh, Message: "Avoid clear text communication (h2c)."},
+	{Rule: "2.1.9", Severity: audit.SeverityLow, Message: "Establish secure connections in internal traffic (avoid insecure_connections internally)"},
+	{Rule: "2.2.1", Severity: audit.SeverityMedium, Message: "Hide the version banner in runtime."},
+	{Rule: "2.2.2", Severity: audit.SeverityHigh, Message: "Enable CORS."},
+	{Rule: "2.2.3", Severity: audit.SeverityHigh, Message: "Avoid passing all input headers to the backend."},
+	{Rule: "2.2.4", Severity: audit.SeverityHigh, Message: "Avoid passing all input query strings to the backend."},
+	{Rule: "2.2.5", Severity: audit.SeverityLow, Message: "Avoid exposing gRPC server without services declared."},
+	{Rule: "3.1.1", Severity: audit.SeverityLow, Message: "Enable a bot detector."},
+	{Rule: "3.1.2", Severity: audit.SeverityHigh, Message: "Implement a rate-limiting strategy and avoid having an All-You-Can-Eat API."},
+	{Rule: "3.1.3", Severity: audit.SeverityHigh, Message: "Protect your backends with a circuit breaker."},
+	{Rule: "3.3.1", Severity: audit.SeverityLow, Message: "Set timeouts to below 3 seconds for improved performance."},
+	{Rule: "3.3.2", Severity: audit.SeverityMedium, Message: "Set timeouts to below 5 seconds for improved performance."},
+	{Rule: "3.3.3", Severity: audit.SeverityHigh, Message: "Set timeouts to below 30 seconds for improved performance."},
+	{Rule: "3.3.4", Severity: audit.SeverityCritical, Message: "Set timeouts to below 1 minute for improved performance."},
+	{Rule: "4.1.1", Severity: audit.SeverityMedium, Message: "Implement a telemetry system for collecting metrics for monitoring and troubleshooting."},
+	{Rule: "4.1.2", Severity: audit.SeverityMedium, Message: "Give your configuration a name for easy identification in metric tracking."},
+	{Rule: "4.1.3", Severity: audit.SeverityHigh, Message: "Avoid duplicating telemetry options to prevent system overload."},
+	{Rule: "4.2.1", Severity: audit.SeverityMedium, Message: "Implement a telemetry system for tracing for monitoring and troubleshooting."},
+	{Rule: "4.3.1", Severity: audit.SeverityMedium, Message: "Use the improved logging component for better log parsing."},
+	{Rule: "5.1.1", Severity: audit.SeverityLow, Message: "Follow a RESTful endpoint structure for improved readability and maintainability."},
+	{Rule: "5.1.2", Severity: audit.SeverityLow, Message: "Disable the /__debug/ endpoint for added security."},
+	{Rule: "5.1.3", Severity: audit.SeverityLow, Message: "Disable the /__echo/ endpoint for added security."},
+	{Rule: "5.1.4", Severity: audit.SeverityLow, Message: "Declare explicit endpoints instead of using wildcards."},
+	{Rule: "5.1.5", Severity: audit.SeverityMedium, Message: "Declare explicit endpoints instead of using /__catchall."},
+	{Rule: "5.1.6", Severity: audit.SeverityMedium, Message: "Avoid using multiple write methods in endpoint definitions."},
+	{Rule: "5.1.7", Severity: audit.SeverityMedium, Message: "Avoid using sequential proxy."},
+	{Rule: "5.2.1", Severity: audit.SeverityCritical, Message: "Ensure all endpoints have at least one backend for proper functionality."},
+	{Rule: "5.2.2", Severity: audit.SeverityLow, Message: "Benefit from the backend for frontend pattern capabilities."},
+	{Rule: "5.2.3", Severity: audit.SeverityLow, Message: "Avoid coupling clients by overusing no-op encoding."},
+	{Rule: "6.1.1", Severity: audit.SeverityLow, Message: "Ensure Async Agents do not start sequentially to avoid overloading the system (+10 agents)."},
+	{Rule: "7.1.1", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin virtualhost. Please visit https://www.krakend.io/docs/enterprise/service-settings/virtual-hosts/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new virtualhost."},
+	{Rule: "7.1.2", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin static-filesystem. Please visit https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new static-filesystem."},
+	{Rule: "7.1.3", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin basic-auth. Please move your configuration to the namespace auth/basic to use the new component. See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ ."},
+	{Rule: "7.1.4", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin wildcard. Please visit https://www.krakend.io/docs/enterprise/endpoints/wildcard/#upgrading-from-the-old-wildcard-plugin-before-v23 to upgrade to the new Wildcard."},
+	{Rule: "7.1.5", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin http-proxy. Please visit https://www.krakend.io/docs/enterprise/backends/http-proxy/#migration-from-old-plugin to upgrade to the new options."},
+	{Rule: "7.1.6", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin static-filesystem. Please visit https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new static-filesystem."},
+	{Rule: "7.1.7", Severity: audit.SeverityHigh, Message: "Avoid using deprecated plugin no-redirect. Please visit https://www.krakend.io/docs/enterprise/backends/client-redirect/#migration-from-old-plugin to upgrade to the new options."},
+	{Rule: "7.2.1", Severity: audit.SeverityHigh, Message: "Avoid using deprecated component telemetry/ganalytics. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry"},
+	{Rule: "7.2.2", Severity: audit.SeverityHigh, Message: "Avoid using deprecated component telemetry/instana. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry"},
+	{Rule: "7.2.3", Severity: audit.SeverityHigh, Message: "Avoid using deprecated component telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to upgrade to OpenTelemetry"},
+	{Rule: "7.3.1", Severity: audit.SeverityMedium, Message: "Avoid using 'private_key' and 'public_key' and use the 'keys' array."},
+}
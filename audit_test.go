@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	audit "github.com/krakendio/krakend-audit"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_auditRecommendationFilters(t *testing.T) {
+	recs := []audit.Recommendation{
+		{Rule: "1.2.1", Severity: audit.SeverityHigh},
+		{Rule: "3.1.1", Severity: audit.SeverityLow},
+		{Rule: "5.2.1", Severity: audit.SeverityCritical},
+	}
+
+	require.Equal(t, recs, enabledRecommendations(recs, nil))
+	require.Equal(t, recs[1:2], enabledRecommendations(recs, splitRuleList("3.1.1, 9.9.9")))
+
+	require.Equal(t, recs, gatedRecommendations(recs, ""))
+	require.Equal(t, []audit.Recommendation{recs[0], recs[2]}, gatedRecommendations(recs, "high"))
+	require.Empty(t, gatedRecommendations(recs[:2], audit.SeverityCritical))
+
+	require.Equal(t, -1, auditSeverityRank("URGENT"))
+}
+
+func Test_auditRules(t *testing.T) {
+	seen := map[string]struct{}{}
+	for _, r := range auditRules {
+		_, dup := seen[r.Rule]
+		require.False(t, dup, r.Rule)
+		seen[r.Rule] = struct{}{}
+		require.GreaterOrEqual(t, auditSeverityRank(r.Severity), 0, r.Rule)
+	}
+}
+
+// Test_auditRules_inSync fails when krakend-audit reports a rule missing from the mirrored list,
+// like after upgrading the dependency
+func Test_auditRules_inSync(t *testing.T) {
+	known := map[string]string{}
+	for _, r := range auditRules {
+		known[r.Rule] = r.Severity
+	}
+
+	full, err := config.NewParser().Parse("./testdata/audit.json")
+	require.NoError(t, err)
+	for _, cfg := range []config.ServiceConfig{full, {Version: 3}} {
+		cfg.Normalize()
+		res, err := audit.Audit(&cfg, nil, auditSeverities)
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Recommendations)
+		for _, r := range res.Recommendations {
+			severity, ok := known[r.Rule]
+			require.True(t, ok, "rule %s is not in auditRules", r.Rule)
+			require.Equal(t, severity, r.Severity, r.Rule)
+		}
+	}
+}
+
+func Test_auditFunc_json(t *testing.T) {
+	origParser, origCfg, origOutput, origFormat, origEnabled := parser, cfgFile, auditOutput, formatTmpl, auditEnabledRules
+	defer func() {
+		parser, cfgFile, auditOutput, formatTmpl, auditEnabledRules = origParser, origCfg, origOutput, origFormat, origEnabled
+	}()
+	parser = jsonParser
+	cfgFile = writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	auditOutput = formatJSON
+	// the template is ignored by the json output, even when it is literally named json
+	formatTmpl = "json"
+	// the recommendations make the command exit, so all of them are filtered out
+	auditEnabledRules = "9.9.9"
+
+	var stdout bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	auditFunc(cmd, nil)
+
+	var res audit.AuditResult
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &res), stdout.String())
+	require.Equal(t, []audit.Recommendation{}, res.Recommendations)
+	require.Contains(t, stdout.String(), `"stats"`)
+}
+
+func Test_listAuditRules_json(t *testing.T) {
+	origOutput := auditOutput
+	defer func() { auditOutput = origOutput }()
+	auditOutput = formatJSON
+
+	var stdout bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	require.NoError(t, listAuditRules(cmd))
+
+	var rules []audit.Recommendation
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &rules))
+	require.Len(t, rules, len(auditRules))
+}
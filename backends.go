@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// backendHostIssue describes a backend declaring no hosts or repeating them
+type backendHostIssue struct {
+	// Agent is set when the backend belongs to the async agent at Index instead of an endpoint
+	Agent bool
+	Index int
+	// Name is the path of the endpoint or the name of the agent
+	Name    string
+	Backend int
+	// Duplicate is the repeated host. It is empty when the backend declares no hosts
+	Duplicate string
+}
+
+// backendHostIssues looks for the backends of the endpoints and the async agents without hosts,
+// once the default host of the service is applied by the parser, and for the hosts repeated in
+// a backend
+func backendHostIssues(v config.ServiceConfig) []backendHostIssue {
+	var issues []backendHostIssue
+	check := func(owner backendHostIssue, backends []*config.Backend) {
+		for j, b := range backends {
+			issue := owner
+			issue.Backend = j
+			if len(b.Host) == 0 {
+				issues = append(issues, issue)
+				continue
+			}
+			seen := map[string]bool{}
+			for _, h := range b.Host {
+				if seen[h] {
+					issue.Duplicate = h
+					issues = append(issues, issue)
+				}
+				seen[h] = true
+			}
+		}
+	}
+	for i, e := range v.Endpoints {
+		check(backendHostIssue{Index: i, Name: e.Endpoint}, e.Backend)
+	}
+	for i, a := range v.AsyncAgents {
+		check(backendHostIssue{Agent: true, Index: i, Name: a.Name}, a.Backend)
+	}
+	return issues
+}
+
+func (i backendHostIssue) Location() string {
+	owner := "/endpoints/"
+	if i.Agent {
+		owner = "/async_agent/"
+	}
+	return owner + strconv.Itoa(i.Index) + "/backend/" + strconv.Itoa(i.Backend) + "/host"
+}
+
+func (i backendHostIssue) String() string {
+	owner := "endpoint"
+	if i.Agent {
+		owner = "async agent"
+	}
+	if i.Duplicate == "" {
+		return fmt.Sprintf("%s %d (%s), backend %d: no host declared and the service has no default host", owner, i.Index, i.Name, i.Backend)
+	}
+	return fmt.Sprintf("%s %d (%s), backend %d: the host %s is repeated", owner, i.Index, i.Name, i.Backend, i.Duplicate)
+}
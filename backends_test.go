@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backendHostIssues(t *testing.T) {
+	v := config.ServiceConfig{
+		Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/a", Backend: []*config.Backend{{Host: []string{"http://a"}}, {}}},
+			{Endpoint: "/b", Backend: []*config.Backend{{Host: []string{"http://a", "http://b", "http://a"}}}},
+		},
+		AsyncAgents: []*config.AsyncAgent{
+			{Name: "consumer", Backend: []*config.Backend{{Host: []string{"amqp://a"}}, {Host: []string{"amqp://a", "amqp://a"}}, {}}},
+		},
+	}
+	issues := backendHostIssues(v)
+	require.Equal(t, []backendHostIssue{
+		{Index: 0, Name: "/a", Backend: 1},
+		{Index: 1, Name: "/b", Backend: 0, Duplicate: "http://a"},
+		{Agent: true, Index: 0, Name: "consumer", Backend: 1, Duplicate: "amqp://a"},
+		{Agent: true, Index: 0, Name: "consumer", Backend: 2},
+	}, issues)
+	require.Equal(t, "/endpoints/0/backend/1/host", issues[0].Location())
+	require.Equal(t, "endpoint 1 (/b), backend 0: the host http://a is repeated", issues[1].String())
+	require.Equal(t, "/async_agent/0/backend/2/host", issues[3].Location())
+	require.Equal(t, "async agent 0 (consumer), backend 2: no host declared and the service has no default host", issues[3].String())
+}
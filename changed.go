@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// errNoGitRepository is returned when the changed files can not be known, as the working
+// directory is not inside a git repository or git is not installed
+var errNoGitRepository = errors.New("not a git repository")
+
+// changedFiles returns the absolute paths of the files of the git repository containing dir
+// that changed since its merge base with the base ref, including the uncommitted changes. The
+// deleted files are excluded and the renamed ones are returned by their new path
+func changedFiles(ctx context.Context, dir, base string) (map[string]bool, error) {
+	root, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, errNoGitRepository
+	}
+	root = strings.TrimSpace(root)
+
+	mergeBase, err := runGit(ctx, dir, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("looking for the merge base with %s: %w", base, err)
+	}
+	// the renames are reported as a deletion and an addition, so only the new path is kept
+	out, err := runGit(ctx, root, "diff", "--name-only", "-z", "--no-renames", "--diff-filter=d", strings.TrimSpace(mergeBase))
+	if err != nil {
+		return nil, fmt.Errorf("listing the files changed since %s: %w", base, err)
+	}
+
+	changed := map[string]bool{}
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			changed[filepath.Join(root, filepath.FromSlash(name))] = true
+		}
+	}
+	return changed, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// filterChangedFiles keeps the files in the changed set, comparing them by their absolute path
+// with the symbolic links of their dir resolved, as git reports them
+func filterChangedFiles(files []string, changed map[string]bool) []string {
+	res := []string{}
+	for _, f := range files {
+		path, err := filepath.Abs(f)
+		if err != nil {
+			continue
+		}
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			path = filepath.Join(dir, filepath.Base(path))
+		}
+		if changed[path] {
+			res = append(res, f)
+		}
+	}
+	return res
+}
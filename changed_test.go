@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_changedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	git("init", "-q", "-b", "main")
+	unchanged := write("unchanged.json", `{"version": 3}`)
+	modified := write("configs/modified.json", `{"version": 3}`)
+	write("deleted.json", `{"version": 3}`)
+	write("renamed.json", `{"version": 3, "name": "renamed"}`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+
+	git("checkout", "-q", "-b", "feature")
+	write("configs/modified.json", `{"version": 3, "port": 8081}`)
+	added := write("added.json", `{"version": 3}`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "feature")
+	git("mv", "renamed.json", "moved.json")
+	git("rm", "-q", "deleted.json")
+	// the uncommitted changes count too
+	uncommitted := write("unchanged.yaml", "version: 3\n")
+	git("add", "unchanged.yaml")
+
+	changed, err := changedFiles(context.Background(), dir, "main")
+	require.NoError(t, err)
+	moved := filepath.Join(dir, "moved.json")
+	require.Equal(t, map[string]bool{modified: true, added: true, moved: true, uncommitted: true}, changed)
+
+	files := []string{unchanged, modified, added, moved, filepath.Join(dir, "deleted.json"), uncommitted}
+	require.Equal(t, []string{modified, added, moved, uncommitted}, filterChangedFiles(files, changed))
+
+	_, err = changedFiles(context.Background(), dir, "unknown")
+	require.Error(t, err)
+	require.False(t, errors.Is(err, errNoGitRepository))
+
+	_, err = changedFiles(context.Background(), t.TempDir(), "main")
+	require.ErrorIs(t, err, errNoGitRepository)
+}
@@ -1,13 +1,22 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	runtimedebug "runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/krakendio/krakend-cobra/v2/dumper"
@@ -18,6 +27,7 @@ import (
 	"github.com/luraproject/lura/v2/logging"
 	"github.com/luraproject/lura/v2/proxy"
 	krakendgin "github.com/luraproject/lura/v2/router/gin"
+	"github.com/luraproject/lura/v2/transport/http/server"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -26,136 +36,1240 @@ import (
 var SchemaURL = "https://www.krakend.io/schema/v%s/krakend.json"
 
 func errorMsg(content string) string {
-	if !IsTTY {
-		return content
-	}
-	return dumper.ColorRed + content + dumper.ColorReset
+	return colorize(UseColors(), dumper.ColorRed, content)
+}
+
+func warnMsg(content string) string {
+	return colorize(UseColors(), dumper.ColorYellow, content)
+}
+
+func okMsg(content string) string {
+	return colorize(UseColors(), dumper.ColorGreen, content)
 }
 
 type LastSourcer interface {
 	LastSource() ([]byte, error)
 }
 
+// parsedContent returns the configuration as the parser read it: the source rendered by the
+// flexible configuration, or the content of the source for the rest of the parsers
+func parsedContent(p config.Parser, src *configSource) ([]byte, error) {
+	if ls, ok := p.(LastSourcer); ok && src.Content == nil {
+		return ls.LastSource()
+	}
+	return src.ReadContent()
+}
+
 func NewCheckCmd(rawSchema string) Command {
 	rawEmbedSchema = rawSchema
 	return CheckCommand
 }
 
-func checkFunc(cmd *cobra.Command, _ []string) { // skipcq: GO-R1005
-	if cfgFile == "" {
-		cmd.Println(errorMsg("Please, provide the path to the configuration file with --config or see all the options with --help"))
-		os.Exit(1) // skipcq: RVV-A0003 // skipcq: RVV-A0003
-		return
+// CheckOptions defines the configuration to check and the checks to run
+type CheckOptions struct {
+	// ConfigFile is the path to the configuration file, or "-" to read it from Stdin
+	ConfigFile string
+	// ConfigContent, when set, is the configuration to check instead of the ConfigFile. It is
+	// named "inline" in the results
+	ConfigContent []byte
+	// ConfigFormat forces the format of the configuration (json, yaml or toml) instead of
+	// detecting it by its extension or content
+	ConfigFormat string
+	// Parser parses the configuration. When nil, the parser of the package is used
+	Parser config.Parser
+	Stdin  io.Reader
+	// Output receives the human oriented messages. They are discarded when nil
+	Output io.Writer
+	Colors bool
+	// Quiet discards the informational messages, keeping only the failures
+	Quiet bool
+	// Verbosity sets how many diagnostic messages about the check itself are printed
+	Verbosity int
+	// MaxErrors caps the lint errors printed, summarizing the rest. All of them are kept in the
+	// result. Zero prints all of them
+	MaxErrors int
+
+	// Lint enables the linting against the official online schema
+	Lint bool
+	// LintNoNetwork enables the linting against the EmbeddedSchema
+	LintNoNetwork  bool
+	EmbeddedSchema string
+	// SchemaPath is the path or URL of a custom schema to lint against
+	SchemaPath string
+	// AutoSchema lints against the schema vendored next to the configuration file when no schema
+	// is selected: the explicit Lint, LintNoNetwork, SchemaPath and SchemaVersion take precedence.
+	// It is the local file referenced by the $schema property of the configuration or
+	// schema/krakend.json
+	AutoSchema bool
+	// LayerSchemas are the paths or URLs of the schemas checked on top of the base one, like
+	// the organization conventions. All the failures are aggregated
+	LayerSchemas []string
+	// SchemaBaseURI is the base URI (or directory) the relative references of the custom
+	// schemas are resolved against. The location of every schema is used by default
+	SchemaBaseURI string
+	// SchemaCache, when set, keeps the compiled schemas for the next checks using it. All those
+	// checks must share the lint options
+	SchemaCache *SchemaCache
+	// SchemaVersion overrides the version (MAJOR.MINOR) of the official online schema
+	SchemaVersion string
+	// SchemaBaseURL replaces the location of the official online schema, like an internal
+	// mirror. It is a URL with a %s placeholder for the version, or the base the /vMAJOR.MINOR/krakend.json
+	// path is appended to. SchemaURL is used when empty
+	SchemaBaseURL string
+	SchemaLoader  SchemaLoaderOptions
+	// Strict reports the properties not described by the schema as lint errors
+	Strict bool
+	// Explain describes the lint findings with the documentation of their location
+	Explain bool
+	// Fragment is the JSON pointer of the only part of the configuration to lint, like a single
+	// endpoint. It is validated against the part of the schema describing it
+	Fragment string
+	// CheckEnv reports the environment variables referenced by the configuration but not set.
+	// They are warnings unless WarnAsError is set
+	CheckEnv bool
+	// CheckDeprecations reports the deprecated keys used by the parsed configuration, with their
+	// replacement. They are warnings unless WarnAsError is set
+	CheckDeprecations bool
+	// CheckNamespaces reports the extra_config namespaces of the parsed configuration not
+	// declared by any component (see RegisterNamespaces). They are warnings unless WarnAsError is set
+	CheckNamespaces bool
+	// TargetVersion (MAJOR.MINOR) is the KrakenD version the configuration is intended for, so
+	// only the keys deprecated by then are reported. The version of the binary is used by default
+	TargetVersion string
+	// ProbeBackends sends a HEAD request to every static backend host of the parsed configuration,
+	// reporting the unreachable ones. They are warnings unless WarnAsError is set
+	ProbeBackends bool
+	// ProbeTimeout is the timeout of every probe and ProbeConcurrency, the number of probes
+	// in flight
+	ProbeTimeout     time.Duration
+	ProbeConcurrency int
+	// TemplateCheck renders the configuration template with the settings of the TemplateDirs,
+	// reporting the syntax errors and the undefined settings before parsing it. It is enabled
+	// by the IncludeRoot of the TemplateDirs too
+	TemplateCheck bool
+	TemplateDirs  TemplateDirs
+	// TraceIncludes prints the tree of the files included by the configuration template, from
+	// the TemplateDirs, and records it in the result
+	TraceIncludes bool
+	// LintIgnoreFile is the path of the file listing the lint findings to ignore
+	LintIgnoreFile string
+	// TolerantJSON removes the comments and the trailing commas of the JSON configurations
+	// before linting them, warning when it alters the content
+	TolerantJSON bool
+	// WarnAsError reports the warnings, like the use of deprecated properties, as errors
+	WarnAsError bool
+	// ContinueOnError runs the rest of the checks after a failure, instead of stopping at the
+	// first one. The checks requiring the parsed configuration are skipped if it can not be parsed,
+	// or if the template check fails with an IncludeRoot
+	ContinueOnError bool
+	// Summary counts the endpoints, backends, async agents and plugins of the parsed
+	// configuration. It is enabled by the Verbosity too
+	Summary bool
+	// Timings records the duration of every phase of the check in the result
+	Timings bool
+
+	// Overrides replace the values at the paths of the parsed configuration before the rest of
+	// the checks, as path=value assignments (see configOverride). The paths must exist, unless
+	// OverridesCreate is set
+	Overrides       []string
+	OverridesCreate bool
+
+	// MaxConfigSize caps the size in bytes of the configuration, as read and as resolved by the
+	// parser, so a huge file or a runaway flexible configuration fails the check instead of
+	// exhausting the memory of the linting. Zero disables the limit
+	MaxConfigSize int64
+
+	// Raw lints the content of the configuration as written, without parsing it, so the flexible
+	// configuration is not rendered. The checks requiring the parsed configuration, like the dump
+	// and the routes testing, are skipped
+	Raw bool
+
+	// OutputConfig is the path of the file the resolved configuration is written to as JSON,
+	// when the check passes. It is a static configuration the parser loads like the original one
+	OutputConfig string
+
+	// DebugLevel sets the verbosity of the dump of the parsed configuration. Zero disables it
+	DebugLevel int
+	DumpPrefix string
+	// DumpFormat selects the format of the dump: text (the default) or json. The json dump
+	// contains the resolved configuration, it is written to DumpOutput and ignores DebugLevel
+	DumpFormat string
+	DumpOutput io.Writer
+	// PrintSource writes the source assembled by the parser to SourceOutput and skips the
+	// rest of the checks. It requires a parser implementing LastSourcer
+	PrintSource  bool
+	SourceOutput io.Writer
+	// DumpOnly skips the linting and the routes testing. The text dump uses at least
+	// the first debug level
+	DumpOnly bool
+	// ListRoutes tests the routes like TestGinRoutes and reports the registered ones
+	ListRoutes    bool
+	TestGinRoutes bool
+	// RoutesPort, when positive, overrides the port of the router testing the routes
+	RoutesPort int
+	// RoutesFreePort tests the routes on a free port chosen by the OS, so the check does not
+	// conflict with a running instance
+	RoutesFreePort bool
+
+	// Context aborts the check when it is done, cancelling the downloads, the probes and the
+	// routes testing in flight. The check never ends by itself when it is nil
+	Context context.Context
+}
+
+func (o CheckOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+func (o CheckOptions) shouldLint() bool {
+	return o.Lint || o.LintNoNetwork || o.SchemaPath != "" || o.SchemaVersion != ""
+}
+
+// autoSchema tells if the vendored schema is looked for, as no schema is selected explicitly
+func (o CheckOptions) autoSchema() bool {
+	return o.AutoSchema && !o.shouldLint()
+}
+
+// targetVersion returns the version the configuration is intended for, or an empty string when
+// it is unknown, like for the custom builds
+func (o CheckOptions) targetVersion() string {
+	if o.TargetVersion != "" {
+		return o.TargetVersion
+	}
+	v, _ := getVersionMinor(core.KrakendVersion)
+	return v
+}
+
+func (o CheckOptions) validate() error {
+	if o.ConfigFile == "" && o.ConfigContent == nil {
+		return errors.New("the path to the configuration file is required")
+	}
+	if o.ConfigFormat != "" && !isSupportedFormat(o.ConfigFormat, configFormats) {
+		return fmt.Errorf("unknown configuration format %q. Supported formats: %s", o.ConfigFormat, strings.Join(configFormats, ", "))
+	}
+	if o.DumpFormat != "" && o.DumpFormat != formatText && o.DumpFormat != formatJSON {
+		return fmt.Errorf("unknown dump format %q. Supported formats: %s, %s", o.DumpFormat, formatText, formatJSON)
+	}
+	if o.SchemaVersion != "" && !schemaVersionPattern.MatchString(o.SchemaVersion) {
+		return invalidSchemaVersion(o.SchemaVersion)
+	}
+	if _, err := schemaURLTemplate(o.SchemaBaseURL); err != nil {
+		return err
+	}
+	if o.Fragment != "" && !strings.HasPrefix(o.Fragment, "/") {
+		return fmt.Errorf("invalid fragment %q. It must be a JSON pointer, like /endpoints/0", o.Fragment)
+	}
+	if o.Fragment != "" && !o.shouldLint() {
+		return errors.New("the fragment requires a schema to lint against")
+	}
+	if o.TolerantJSON && !o.shouldLint() {
+		return errors.New("the tolerant JSON mode requires a schema to lint against")
+	}
+	if o.Raw && !o.shouldLint() {
+		return errors.New("the raw validation requires a schema to lint against")
+	}
+	if o.Raw && (o.DumpOnly || o.PrintSource || o.OutputConfig != "") {
+		return errors.New("the raw validation does not parse the configuration, so it can not be dumped")
+	}
+	if o.PrintSource && o.OutputConfig != "" {
+		return errors.New("printing the source skips the rest of the checks, so the resolved configuration can not be written")
+	}
+	if o.Raw && len(o.Overrides) > 0 {
+		return errors.New("the raw validation does not parse the configuration, so it can not be overridden")
+	}
+	for _, def := range o.Overrides {
+		if _, err := parseConfigOverride(def); err != nil {
+			return err
+		}
+	}
+	if o.MaxErrors < 0 {
+		return fmt.Errorf("invalid max errors %d. It can not be negative", o.MaxErrors)
+	}
+	if o.TargetVersion != "" && !schemaVersionPattern.MatchString(o.TargetVersion) {
+		return fmt.Errorf("invalid target version %q. Use the MAJOR.MINOR format, like 2.6", o.TargetVersion)
+	}
+	if o.MaxConfigSize < 0 {
+		return fmt.Errorf("invalid max config size %d. It can not be negative", o.MaxConfigSize)
+	}
+	if o.ProbeBackends && o.ProbeTimeout <= 0 {
+		return fmt.Errorf("invalid probe timeout %s. It must be greater than zero", o.ProbeTimeout)
+	}
+	if o.ProbeBackends && o.ProbeConcurrency < 1 {
+		return fmt.Errorf("invalid probe concurrency %d. It must be at least 1", o.ProbeConcurrency)
+	}
+	if o.RoutesPort < 0 || o.RoutesPort > 65535 {
+		return fmt.Errorf("invalid routes port %d", o.RoutesPort)
+	}
+	stdinSchemas := 0
+	for _, path := range append([]string{o.SchemaPath}, o.LayerSchemas...) {
+		if path == stdinConfig {
+			stdinSchemas++
+		}
+	}
+	if stdinSchemas > 1 || (stdinSchemas > 0 && o.ConfigFile == stdinConfig) {
+		return fmt.Errorf("the standard input (%s) can only be used once, for the configuration or for a schema", stdinConfig)
+	}
+	if isConfigURL(o.ConfigFile) {
+		if err := o.SchemaLoader.validate(); err != nil {
+			return err
+		}
+	}
+	if len(o.LayerSchemas) > 0 && !o.shouldLint() {
+		return errors.New("the layered schemas require a base schema to lint against")
+	}
+	if o.shouldLint() && (!o.LintNoNetwork || len(o.LayerSchemas) > 0) {
+		return o.SchemaLoader.validate()
+	}
+	return nil
+}
+
+// Check runs all the enabled checks against a single configuration file. The failures found
+// are reported in the result. The returned error is reserved to invalid options
+func Check(opts CheckOptions) (CheckResult, error) { // skipcq: GO-R1005
+	if err := opts.validate(); err != nil {
+		return CheckResult{}, err
 	}
 
-	cmd.Printf("Parsing configuration file: %s\n", cfgFile)
+	p := opts.Parser
+	if p == nil {
+		p = parser
+	}
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	in := opts.Stdin
+	ctx := opts.context()
+	if opts.SchemaLoader.Context == nil {
+		opts.SchemaLoader.Context = ctx
+	}
 
-	v, err := parser.Parse(cfgFile)
+	r := newCheckReporter(opts.Output, opts.Colors)
+	r.quiet = opts.Quiet
+	r.verbosity = opts.Verbosity
+	r.maxErrors = opts.MaxErrors
+	if opts.Timings {
+		r.timings = true
+		defer r.printTimings()
+	}
+	r.result.ConfigFile = opts.ConfigFile
+	if err := ctx.Err(); err != nil {
+		r.aborted(r.result.ConfigFile, err)
+		return r.result, nil
+	}
+	var src *configSource
+	var err error
+	switch {
+	case opts.ConfigContent != nil:
+		r.result.ConfigFile = inlineConfigName
+		format := opts.ConfigFormat
+		if format == "" {
+			format = documentFormat("", opts.ConfigContent)
+		}
+		src, err = newTempConfigSource(inlineConfigName, opts.ConfigContent, "."+format)
+	case isConfigURL(opts.ConfigFile):
+		src, err = fetchConfigSource(opts.ConfigFile, opts.ConfigFormat, opts.SchemaLoader, opts.MaxConfigSize)
+	case opts.ConfigFile == stdinConfig:
+		r.result.ConfigFile = "stdin"
+		fallthrough
+	default:
+		src, err = openConfigSource(in, opts.ConfigFile, opts.ConfigFormat, opts.MaxConfigSize)
+	}
+	if err := ctx.Err(); err != nil {
+		r.aborted(r.result.ConfigFile, err)
+		return r.result, nil
+	}
 	if err != nil {
-		cmd.Println(errorMsg("ERROR parsing the configuration file:") + fmt.Sprintf("\t%s\n", err.Error()))
-		os.Exit(1) // skipcq: RVV-A0003 // skipcq: RVV-A0003
-		return
+		r.fail(stageLoad, r.result.ConfigFile, "ERROR loading the configuration content:", err)
+		return r.result, nil
+	}
+	defer src.Close()
+	if size, err := src.Size(); err == nil {
+		if err := checkConfigSize("the configuration", size, opts.MaxConfigSize); err != nil {
+			r.fail(stageLoad, src.Name, "ERROR loading the configuration content:", err)
+			return r.result, nil
+		}
 	}
 
-	shouldLint := lintCurrentSchema || lintNoNetwork || (lintCustomSchemaPath != "")
+	r.infof("Parsing configuration file: %s\n", src.Name)
 
-	if shouldLint {
-		var data []byte
-		var err error
-		if ls, ok := parser.(LastSourcer); ok {
-			data, err = ls.LastSource()
+	if opts.CheckEnv {
+		data, err := src.ReadContent()
+		if err != nil {
+			r.fail(stageLoad, src.Name, "ERROR loading the configuration content:", src.Error(err))
+			return r.result, nil
+		}
+		if unset := unsetEnvReferences(src.Path, data, os.LookupEnv); len(unset) > 0 {
+			r.envUnset(src.Name, unset, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if opts.TemplateCheck || opts.TemplateDirs.IncludeRoot != "" {
+		data, err := src.ReadContent()
+		if err != nil {
+			r.fail(stageLoad, src.Name, "ERROR loading the configuration content:", src.Error(err))
+			return r.result, nil
+		}
+		if errs := checkTemplates(src.Name, data, opts.TemplateDirs); len(errs) > 0 {
+			r.templateFailed(errs)
+			// the parser would read the includes outside the root, so it is never reached
+			if !opts.ContinueOnError || opts.TemplateDirs.IncludeRoot != "" {
+				return r.result, nil
+			}
+		} else {
+			r.debugf(1, "Templates checked\n")
+		}
+	}
+
+	if opts.TraceIncludes {
+		data, err := src.ReadContent()
+		if err != nil {
+			r.fail(stageLoad, src.Name, "ERROR loading the configuration content:", src.Error(err))
+			return r.result, nil
+		}
+		includes, err := traceIncludes(src.Name, data, opts.TemplateDirs)
+		if err != nil {
+			r.fail(stageTemplate, src.Name, "ERROR tracing the includes:", err)
+			if !opts.ContinueOnError {
+				return r.result, nil
+			}
 		} else {
-			data, err = os.ReadFile(cfgFile)
+			r.printIncludes(includes)
+		}
+	}
+
+	if opts.Raw {
+		r.debugf(1, "Linting the raw configuration, without parsing it\n")
+		linted := lintConfig(r, opts, nil, src)
+		if err := ctx.Err(); err != nil {
+			r.aborted(src.Name, err)
+		} else if linted {
+			r.infof("%s\n", r.okMsg("Syntax OK!"))
+		}
+		return r.result, nil
+	}
+
+	start := time.Now()
+	v, err := p.Parse(src.Path)
+	r.debugf(1, "Configuration parsed in %s\n", time.Since(start))
+	r.timing(phaseParse, time.Since(start))
+	if err != nil {
+		r.fail(stageParse, src.Name, "ERROR parsing the configuration file:", src.Error(err))
+		return r.result, nil
+	}
+	if ls, ok := p.(LastSourcer); ok && opts.MaxConfigSize > 0 {
+		// the flexible configuration may render a source much bigger than the file
+		if data, err := ls.LastSource(); err == nil {
+			if err := checkConfigSize("the resolved configuration", int64(len(data)), opts.MaxConfigSize); err != nil {
+				r.fail(stageParse, src.Name, "ERROR parsing the configuration file:", err)
+				return r.result, nil
+			}
+		}
+	}
+
+	if len(opts.Overrides) > 0 {
+		overrides := make([]configOverride, len(opts.Overrides))
+		for i, def := range opts.Overrides {
+			overrides[i], _ = parseConfigOverride(def)
 		}
+		overridden, err := overrideConfig(p, src, overrides, opts.OverridesCreate)
+		if err != nil {
+			r.fail(stageParse, src.Name, "ERROR overriding the configuration:", err)
+			return r.result, nil
+		}
+		defer overridden.Close()
+		src = overridden
+		if v, err = p.Parse(src.Path); err != nil {
+			r.fail(stageParse, src.Name, "ERROR parsing the overridden configuration:", src.Error(err))
+			return r.result, nil
+		}
+		r.debugf(1, "%d override(s) applied\n", len(overrides))
+	}
 
+	if opts.PrintSource {
+		ls, ok := p.(LastSourcer)
+		if !ok {
+			r.fail(stageLoad, src.Name, "ERROR printing the configuration source:", errors.New("the parser does not expose the assembled source (it does not implement LastSourcer)"))
+			return r.result, nil
+		}
+		data, err := ls.LastSource()
+		if err == nil && opts.SourceOutput != nil {
+			_, err = opts.SourceOutput.Write(data)
+		}
 		if err != nil {
-			cmd.Println(errorMsg("ERROR loading the configuration content:") + fmt.Sprintf("\t%s\n", err.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+			r.fail(stageLoad, src.Name, "ERROR printing the configuration source:", src.Error(err))
 		}
+		return r.result, nil
+	}
+
+	if opts.DumpOnly && opts.DebugLevel == 0 {
+		opts.DebugLevel = 1
+	}
 
-		var raw interface{}
-		if err := json.Unmarshal(data, &raw); err != nil {
-			cmd.Println(errorMsg("ERROR converting configuration content to JSON:") + fmt.Sprintf("\t%s\n", err.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+	if (opts.shouldLint() || opts.autoSchema()) && !opts.DumpOnly {
+		linted := lintConfig(r, opts, p, src)
+		if err := ctx.Err(); err != nil {
+			r.aborted(src.Name, err)
+			return r.result, nil
 		}
+		if !linted && !opts.ContinueOnError {
+			return r.result, nil
+		}
+	}
 
-		var sch *jsonschema.Schema
-		var compilationErr error
-		if lintNoNetwork {
-			rawSchema, parseError := jsonschema.UnmarshalJSON(strings.NewReader(rawEmbedSchema))
-			if parseError != nil {
-				cmd.Println(errorMsg("ERROR parsing the embed schema:") + fmt.Sprintf("\t%s\n", parseError.Error()))
-				os.Exit(1) // skipcq: RVV-A0003
-				return
+	if opts.CheckDeprecations && !opts.DumpOnly {
+		if uses := deprecatedUses(resolvedConfig(v), deprecatedKeysFor(opts.targetVersion())); len(uses) > 0 {
+			r.deprecatedKeysUsed(src.Name, uses, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
 			}
+		}
+	}
 
-			compiler := jsonschema.NewCompiler()
-			compiler.AddResource("schema.json", rawSchema)
+	if opts.CheckNamespaces && !opts.DumpOnly {
+		if uses := unknownNamespaces(v); len(uses) > 0 {
+			r.unknownNamespacesUsed(src.Name, uses, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
 
-			sch, compilationErr = compiler.Compile("schema.json")
-		} else {
-			if lintCustomSchemaPath == "" {
-				lintCustomSchemaPath = fmt.Sprintf(SchemaURL, getVersionMinor(core.KrakendVersion))
+	if !opts.DumpOnly {
+		if collisions := endpointCollisions(v.Endpoints); len(collisions) > 0 {
+			r.endpointsCollide(src.Name, collisions)
+			if !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if !opts.DumpOnly {
+		if issues := backendHostIssues(v); len(issues) > 0 {
+			// only the backends without hosts fail the check, unless the warnings are errors
+			failed := len(r.result.Errors)
+			r.backendHostsInvalid(src.Name, issues, opts.WarnAsError)
+			if len(r.result.Errors) > failed && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if !opts.DumpOnly {
+		if issues := tlsIssues(v.TLS); len(issues) > 0 {
+			r.tlsFilesInvalid(src.Name, issues, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if !opts.DumpOnly {
+		// the declared timeouts of the backends are only in the document, unless it can not be read
+		var doc interface{}
+		if data, err := parsedContent(p, src); err == nil {
+			doc, _, _ = decodeDocument(src.Path, data)
+		}
+		if issues := timeoutIssues(v, doc); len(issues) > 0 {
+			r.timeoutsInverted(src.Name, issues, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if opts.ProbeBackends && !opts.DumpOnly {
+		start := time.Now()
+		failed := probeBackends(ctx, backendHosts(v), opts.ProbeTimeout, opts.ProbeConcurrency)
+		r.debugf(1, "Backends probed in %s\n", time.Since(start))
+		r.timing(phaseProbe, time.Since(start))
+		if err := ctx.Err(); err != nil {
+			r.aborted(src.Name, err)
+			return r.result, nil
+		}
+		if len(failed) > 0 {
+			r.backendsUnreachable(src.Name, failed, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	start = time.Now()
+	if opts.DumpFormat == formatJSON {
+		if opts.DumpOutput != nil {
+			enc := newJSONEncoder(opts.DumpOutput)
+			err := enc.Encode(resolvedConfig(v))
+			r.timing(phaseDump, time.Since(start))
+			if err != nil {
+				r.fail(stageDump, src.Name, "ERROR dumping the configuration file:", err)
+				if !opts.ContinueOnError {
+					return r.result, nil
+				}
+			}
+		}
+	} else if opts.DebugLevel > 0 && opts.Output != nil {
+		dumpCmd := &cobra.Command{}
+		dumpCmd.SetOut(opts.Output)
+		cc := dumper.NewWithColors(dumpCmd, opts.DumpPrefix, opts.DebugLevel, opts.Colors)
+		err := cc.Dump(v)
+		r.timing(phaseDump, time.Since(start))
+		if err != nil {
+			r.dumpFailed(src.Name, err)
+			if !opts.ContinueOnError {
+				return r.result, nil
+			}
+		}
+	}
+
+	if (opts.TestGinRoutes || opts.ListRoutes) && !opts.DumpOnly {
+		switch {
+		case opts.RoutesFreePort:
+			p, err := freePort()
+			if err != nil {
+				r.fail(stageRoutes, src.Name, "ERROR looking for a free port:", err)
+				return r.result, nil
+			}
+			v.Port = p
+			r.debugf(1, "Testing the routes on the free port %d\n", p)
+		case opts.RoutesPort > 0:
+			v.Port = opts.RoutesPort
+		}
+
+		start = time.Now()
+		var err error
+		var routes []RouteInfo
+		err = runCancelable(ctx, func() error {
+			if !opts.ListRoutes {
+				return RunRouterFunc(v)
+			}
+			var err error
+			routes, err = ListRoutesFunc(v)
+			return err
+		})
+		r.debugf(1, "Routes tested in %s\n", time.Since(start))
+		r.timing(phaseRoutes, time.Since(start))
+		if err := ctx.Err(); err != nil {
+			r.aborted(src.Name, err)
+			return r.result, nil
+		}
+		r.result.Routes = routes
+		if err != nil {
+			r.fail(stageRoutes, src.Name, "ERROR testing the configuration file:", err)
+			return r.result, nil
+		}
+		r.result.RoutesTested = true
+		r.printRoutes()
+	}
+
+	if opts.Summary || opts.Verbosity > 0 {
+		summary := newConfigSummary(v)
+		r.result.Summary = &summary
+	}
+	if len(r.result.Errors) == 0 && opts.OutputConfig != "" {
+		if err := writeResolvedConfig(p, v, opts.OutputConfig); err != nil {
+			r.fail(stageDump, src.Name, "ERROR writing the resolved configuration:", err)
+			return r.result, nil
+		}
+		r.infof("Resolved configuration saved to %s\n", opts.OutputConfig)
+	}
+	if len(r.result.Errors) == 0 {
+		r.printSummary()
+		r.infof("%s\n", r.okMsg("Syntax OK!"))
+	}
+	return r.result, nil
+}
+
+// lintConfig lints the source of the configuration, reporting the findings. It returns false
+// when the linting fails or can not be completed
+func lintConfig(r *checkReporter, opts CheckOptions, p config.Parser, src *configSource) bool {
+	data, err := parsedContent(p, src)
+	if err != nil {
+		r.fail(stageLoad, src.Name, "ERROR loading the configuration content:", src.Error(err))
+		return false
+	}
+
+	var suppressions *lintSuppressions
+	if opts.LintIgnoreFile != "" {
+		if suppressions, err = readLintSuppressions(opts.LintIgnoreFile); err != nil {
+			r.fail(stageLoad, opts.LintIgnoreFile, "ERROR reading the lint ignore file:", err)
+			return false
+		}
+	}
+
+	if opts.TolerantJSON && documentFormat(src.Path, data) == formatJSON {
+		var changes tolerantJSONChanges
+		if data, changes = tolerantJSON(data); changes.altered() {
+			r.tolerantJSONApplied(src.Name, changes)
+		}
+	}
+
+	raw, positions, err := decodeDocument(src.Path, data)
+	if err != nil {
+		r.fail(stageLoad, src.Name, "ERROR converting configuration content to JSON:", src.Error(err))
+		return false
+	}
+
+	cache := opts.SchemaCache
+	if opts.autoSchema() {
+		path := discoverSchema(src.Name, raw)
+		if path == "" {
+			// there is nothing to lint against
+			return true
+		}
+		r.infof("Using the schema %s found next to the configuration\n", path)
+		opts.SchemaPath = path
+		// every file can vendor its own schema, so it is not shared with the rest of the checks
+		cache = nil
+	}
+
+	var schemas []*jsonschema.Schema
+	if cache != nil {
+		schemas = cache.compiled(r, func() []*jsonschema.Schema { return compileLintSchemas(r, opts) })
+	} else {
+		schemas = compileLintSchemas(r, opts)
+	}
+	if schemas == nil {
+		return false
+	}
+
+	if r.result.SchemaUsed == "embedded" {
+		if version, ok := staleEmbeddedSchema(opts.EmbeddedSchema, core.KrakendVersion); ok {
+			r.schemaStale(version, core.KrakendVersion, opts.WarnAsError)
+			if opts.WarnAsError && !opts.ContinueOnError {
+				return false
+			}
+		}
+	}
+
+	start := time.Now()
+	if opts.Fragment != "" {
+		if raw, schemas, err = fragmentOf(raw, schemas, opts.Fragment); err != nil {
+			r.fail(stageLint, src.Name, "ERROR resolving the fragment:", err)
+			return false
+		}
+	}
+
+	findings, warnings := validateDocument(schemas, raw, opts.Strict)
+	prefixFindings(opts.Fragment, findings)
+	prefixFindings(opts.Fragment, warnings)
+	if opts.Explain {
+		explainFindings(findings)
+		explainFindings(warnings)
+	}
+	if opts.WarnAsError {
+		findings = append(findings, warnings...)
+		warnings = nil
+	}
+	r.debugf(1, "Configuration linted in %s\n", time.Since(start))
+	r.timing(phaseValidate, time.Since(start))
+
+	var ignored, ignoredWarnings []LintFinding
+	findings, ignored = suppressions.filter(findings)
+	warnings, ignoredWarnings = suppressions.filter(warnings)
+	if ignored = append(ignored, ignoredWarnings...); len(ignored) > 0 {
+		locateFindings(positions, ignored)
+		r.lintIgnored(src.Name, ignored)
+	}
+	if len(warnings) > 0 {
+		locateFindings(positions, warnings)
+		r.lintWarned(src.Name, warnings)
+	}
+	if len(findings) > 0 {
+		locateFindings(positions, findings)
+		r.lintFailed(src.Name, findings)
+		return false
+	}
+	r.result.LintPassed = true
+	return true
+}
+
+// compileLintSchemas compiles the base schema followed by the layers. It returns nil when any
+// of them fails, once the failure is reported
+func compileLintSchemas(r *checkReporter, opts CheckOptions) []*jsonschema.Schema {
+	loaderOpts := opts.SchemaLoader
+	if loaderOpts.Logf == nil && r.verbosity >= 2 {
+		loaderOpts.Logf = func(format string, a ...interface{}) { r.debugf(2, format, a...) }
+	}
+	// the documents are fetched while compiling, so the loader measures the time spent fetching
+	var fetched time.Duration
+	defer func(begin time.Time) {
+		r.timing(phaseSchemaFetch, fetched)
+		r.timing(phaseSchemaCompile, time.Since(begin)-fetched)
+	}(time.Now())
+
+	var loader jsonschema.URLLoader
+	compile := func(path string, custom bool) (*jsonschema.Schema, bool) {
+		if loader == nil {
+			l, err := newSchemaLoader(loaderOpts)
+			if err != nil {
+				r.fail(stageSchema, path, "ERROR preparing the schema loader:", err)
+				return nil, false
 			}
+			loader = timedLoader{loader: l, elapsed: &fetched}
+		}
+		compiler := newSchemaCompiler()
+		compiler.UseLoader(loader)
+		resource := path
+		if custom {
+			var doc interface{}
+			var err error
+			resource, doc, err = customSchemaResource(loader, path, opts.SchemaBaseURI, opts.Stdin)
+			if err != nil {
+				r.fail(stageSchema, path, "ERROR loading the schema:", err)
+				return nil, false
+			}
+			if doc != nil {
+				if err := compiler.AddResource(resource, doc); err != nil {
+					r.fail(stageSchema, path, "ERROR compiling the schema:", err)
+					return nil, false
+				}
+			}
+		}
+		sch, err := compiler.Compile(resource)
+		if err != nil {
+			r.fail(stageSchema, path, "ERROR compiling the schema:", err)
+			return nil, false
+		}
+		return sch, true
+	}
+
+	start := time.Now()
+	var base *jsonschema.Schema
+	if opts.LintNoNetwork {
+		r.result.SchemaUsed = "embedded"
+		var err error
+		if base, err = compileEmbeddedSchema([]byte(opts.EmbeddedSchema)); err != nil {
+			r.fail(stageSchema, r.result.SchemaUsed, "ERROR loading the embedded schema:", err)
+			return nil
+		}
+	} else {
+		schemaPath := opts.SchemaPath
+		if schemaPath == "" {
+			var err error
+			if schemaPath, err = onlineSchemaURL(opts.SchemaBaseURL, opts.SchemaVersion); err != nil {
+				r.fail(stageSchema, "", "ERROR resolving the schema:", err)
+				return nil
+			}
+		}
+		r.result.SchemaUsed = schemaPath
+
+		var ok bool
+		if base, ok = compile(schemaPath, opts.SchemaPath != ""); !ok {
+			return nil
+		}
+	}
+	r.debugf(1, "Schema %s resolved in %s\n", r.result.SchemaUsed, time.Since(start))
+
+	schemas := []*jsonschema.Schema{base}
+	for _, layer := range opts.LayerSchemas {
+		start = time.Now()
+		sch, ok := compile(layer, true)
+		if !ok {
+			return nil
+		}
+		r.debugf(1, "Schema %s resolved in %s\n", layer, time.Since(start))
+		schemas = append(schemas, sch)
+	}
+	return schemas
+}
+
+// SchemaCache keeps the schemas compiled by a check, so the rest of the checks of a run reuse
+// them. The compilation failures are not cached
+type SchemaCache struct {
+	mu      sync.Mutex
+	schemas []*jsonschema.Schema
+	used    string
+}
+
+// compiled returns the cached schemas or, when there are none, the ones compiled by compile.
+// The concurrent checks wait for the first one compiling them
+func (c *SchemaCache) compiled(r *checkReporter, compile func() []*jsonschema.Schema) []*jsonschema.Schema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.schemas != nil {
+		r.result.SchemaUsed = c.used
+		r.debugf(1, "Schema %s reused\n", c.used)
+		return c.schemas
+	}
+	schemas := compile()
+	if schemas != nil {
+		c.schemas, c.used = schemas, r.result.SchemaUsed
+	}
+	return schemas
+}
+
+// timedLoader accumulates the time spent loading documents
+type timedLoader struct {
+	loader  jsonschema.URLLoader
+	elapsed *time.Duration
+}
+
+func (l timedLoader) Load(url string) (interface{}, error) {
+	start := time.Now()
+	defer func() { *l.elapsed += time.Since(start) }()
+	return l.loader.Load(url)
+}
+
+// onlineSchemaBaseURL returns the --schema-base-url flag or, when it is not set, the
+// KRAKEND_SCHEMA_BASE_URL env var
+func onlineSchemaBaseURL() string {
+	if schemaBaseURL != "" {
+		return schemaBaseURL
+	}
+	return os.Getenv("KRAKEND_SCHEMA_BASE_URL")
+}
+
+// schemaProgressf returns the receiver of the progress of the slow schema downloads. They are
+// reported only in interactive terminals
+func schemaProgressf(cmd *cobra.Command, quiet bool) func(format string, a ...interface{}) {
+	if !IsTTY || quiet {
+		return nil
+	}
+	return cmd.PrintErrf
+}
+
+func checkTemplateDirs() TemplateDirs {
+	dirs := templateDirsFromEnv()
+	dirs.IncludeRoot = checkIncludeRoot
+	return dirs
+}
+
+// checkOptionsFromFlags returns the options of the check command for the received file
+func checkOptionsFromFlags(cmd *cobra.Command, file string) CheckOptions {
+	// the custom schemas are layered on top of the official one when it is selected. Otherwise,
+	// the first of them is the base schema
+	var baseSchema string
+	layerSchemas := lintCustomSchemaPaths
+	if !lintCurrentSchema && !lintNoNetwork && schemaVersion == "" && len(layerSchemas) > 0 {
+		baseSchema, layerSchemas = layerSchemas[0], layerSchemas[1:]
+	}
+	lint, lintOffline, version, fragment := lintCurrentSchema, lintNoNetwork, schemaVersion, lintFragment
+	if checkNoLint {
+		baseSchema, layerSchemas = "", nil
+		lint, lintOffline, version, fragment = false, false, "", ""
+	}
+	opts := CheckOptions{
+		ConfigFile:     file,
+		ConfigFormat:   checkConfigFormat,
+		Stdin:          cmd.InOrStdin(),
+		Output:         cmd.ErrOrStderr(),
+		Colors:         UseColors(),
+		Quiet:          checkQuiet,
+		MaxErrors:      lintMaxErrors,
+		Verbosity:      checkVerbose,
+		Lint:           lint,
+		LintNoNetwork:  lintOffline,
+		EmbeddedSchema: rawEmbedSchema,
+		SchemaPath:     baseSchema,
+		AutoSchema:     !checkNoAutoSchema && !checkNoLint,
+		SchemaBaseURI:  schemaBaseURI,
+		LayerSchemas:   layerSchemas,
+		SchemaVersion:  version,
+		SchemaBaseURL:  onlineSchemaBaseURL(),
+		SchemaLoader: SchemaLoaderOptions{
+			Timeout:      schemaTimeout,
+			Retries:      schemaRetries,
+			RetryBackoff: schemaRetryBackoff,
+			Proxy:        schemaProxy,
+			Headers:      schemaHeaders,
+			CacheTTL:     schemaCacheTTL,
+			NoCache:      schemaNoCache,
+			Progressf:    schemaProgressf(cmd, checkQuiet),
+		},
+		Strict:            lintStrict,
+		Raw:               checkRaw,
+		Fragment:          fragment,
+		Explain:           lintExplain,
+		WarnAsError:       lintWarnAsError,
+		LintIgnoreFile:    lintIgnoreFile,
+		TolerantJSON:      lintTolerantJSON,
+		CheckEnv:          checkEnv,
+		CheckDeprecations: checkDeprecations,
+		CheckNamespaces:   checkNamespaces,
+		TargetVersion:     checkTargetVersion,
+		ProbeBackends:     checkProbeBackends,
+		ProbeTimeout:      checkProbeTimeout,
+		ProbeConcurrency:  checkProbeConcurrency,
+		TemplateCheck:     checkTemplate,
+		TemplateDirs:      checkTemplateDirs(),
+		TraceIncludes:     checkTraceIncludes,
+		Overrides:         checkOverrides,
+		MaxConfigSize:     checkMaxConfigSize,
+		OutputConfig:      checkOutputConfig,
+		OverridesCreate:   checkOverridesCreate,
+		DebugLevel:        checkDebug,
+		DumpPrefix:        checkDumpPrefix,
+		DumpFormat:        checkDumpFormat,
+		DumpOnly:          checkDumpOnly,
+		ListRoutes:        checkListRoutes,
+		TestGinRoutes:     checkGinRoutes,
+		ContinueOnError:   !checkFailFast,
+		Timings:           checkTimings,
+		Summary:           checkSummary,
+	}
+	if checkDumpFormat == formatJSON {
+		opts.DumpOutput = cmd.OutOrStdout()
+	}
+	if checkPrintSource {
+		opts.SourceOutput = cmd.OutOrStdout()
+	}
+	switch {
+	case checkPort == 0:
+		opts.RoutesFreePort = true
+	case checkPort > 0:
+		opts.RoutesPort = checkPort
+	}
+	return opts
+}
+
+func checkFunc(cmd *cobra.Command, _ []string) error {
+	// the flags are already parsed, so the usage is not relevant anymore and
+	// the failures are reported by the command itself
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	if !isSupportedFormat(checkOutputFormat, checkFormats) {
+		return &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf("unknown output format %q. Supported formats: %s", checkOutputFormat, strings.Join(checkFormats, ", "))}
+	}
+
+	if checkReportFile != "" && checkOutputFormat == formatText {
+		return checkUsageError(cmd, "ERROR writing the report file:", fmt.Errorf("the report file requires a structured output format: %s", strings.Join(checkFormats[1:], ", ")))
+	}
+	if checkDumpFormat == formatJSON && checkStructuredStdout() {
+		return checkUsageError(cmd, "ERROR dumping the configuration file:", fmt.Errorf("the json dump is written to stdout, so it requires the %s output format or a report file", formatText))
+	}
+	if checkPrintSource && checkStructuredStdout() {
+		return checkUsageError(cmd, "ERROR printing the configuration source:", fmt.Errorf("the source is written to stdout, so it requires the %s output format or a report file", formatText))
+	}
+
+	if checkJobs < 1 {
+		return checkUsageError(cmd, "ERROR checking the configuration file:", fmt.Errorf("invalid number of jobs %d. It must be at least 1", checkJobs))
+	}
+
+	if checkPort < -1 || checkPort > 65535 {
+		return checkUsageError(cmd, "ERROR testing the configuration file:", fmt.Errorf("invalid port %d. Use 0 for a free port or -1 for the port of the configuration", checkPort))
+	}
+
+	if (checkGinRoutes || checkListRoutes) && !checkBuildOnly && runTimeout <= 0 {
+		return checkUsageError(cmd, "ERROR testing the configuration file:", fmt.Errorf("invalid run timeout %s. It must be greater than zero", runTimeout))
+	}
+
+	if len(checkConfigFiles) == 0 && checkConfigInline == "" {
+		return checkUsageError(cmd, "Please, provide the path to the configuration file with --config or see all the options with --help", nil)
+	}
+
+	files := []string{inlineConfigName}
+	var err error
+	if checkConfigInline == "" {
+		if files, err = expandConfigFiles(checkConfigFiles); err != nil {
+			return checkUsageError(cmd, "ERROR resolving the configuration files:", err)
+		}
+	}
+
+	if checkNoLint && !checkQuiet {
+		if ignored := noLintIgnoredFlags(cmd); len(ignored) > 0 {
+			cmd.PrintErrln(warnMsg("WARNING the linting is disabled:") + fmt.Sprintf("\t%s have no effect with --no-lint\n", strings.Join(ignored, ", ")))
+		}
+	}
+
+	// Ctrl-C aborts the checks in flight, instead of leaving the downloads and the routers behind
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-			httpLoader := SchemaHttpLoader(http.Client{
-				Timeout: 10 * time.Second,
-			})
+	if checkChanged {
+		if isStdinUsed(files) || isConfigURLUsed(files) {
+			return checkUsageError(cmd, "ERROR looking for the changed configuration files:", fmt.Errorf("--changed requires files, so the configuration can not be downloaded or read from stdin (%s)", stdinConfig))
+		}
+		if files, err = changedConfigFiles(ctx, cmd, files); err != nil {
+			return checkUsageError(cmd, "ERROR looking for the changed configuration files:", err)
+		}
+	}
+
+	if checkOutputConfig != "" && len(files) > 1 {
+		return checkUsageError(cmd, "ERROR writing the resolved configuration:", fmt.Errorf("--output-config requires a single configuration file, but %d were found", len(files)))
+	}
+
+	if checkWatch {
+		if checkConfigInline != "" || isStdinUsed(files) || isStdinUsed(lintCustomSchemaPaths) || isConfigURLUsed(files) {
+			return checkUsageError(cmd, "ERROR watching the configuration:", fmt.Errorf("the watch mode requires files, so the configuration and the schemas can not be inline, downloaded or read from stdin (%s)", stdinConfig))
+		}
+		return watchChecks(ctx, cmd, checkWatchedPaths(files), func() { runChecks(ctx, cmd, files, nil) })
+	}
 
-			loader := jsonschema.SchemeURLLoader{
-				"file":  jsonschema.FileLoader{},
-				"http":  &httpLoader,
-				"https": &httpLoader,
+	// a schema read from stdin is shared by all the files
+	var stdinSchema []byte
+	for _, path := range lintCustomSchemaPaths {
+		if path == stdinConfig && !checkNoLint {
+			if stdinSchema, err = io.ReadAll(cmd.InOrStdin()); err != nil {
+				return checkUsageError(cmd, "ERROR reading the schema from stdin:", err)
 			}
-			compiler := jsonschema.NewCompiler()
-			compiler.UseLoader(loader)
+			break
+		}
+	}
+	return runChecks(ctx, cmd, files, stdinSchema)
+}
 
-			sch, compilationErr = compiler.Compile(lintCustomSchemaPath)
+// changedConfigFiles keeps the files changed since the --base ref. All of them are kept, with a
+// warning, when the changed files can not be known
+func changedConfigFiles(ctx context.Context, cmd *cobra.Command, files []string) ([]string, error) {
+	changed, err := changedFiles(ctx, ".", checkChangedBase)
+	if errors.Is(err, errNoGitRepository) {
+		if !checkQuiet {
+			cmd.PrintErrln(warnMsg("WARNING looking for the changed configuration files:") + fmt.Sprintf("\t%s. All the configuration files are checked\n", err.Error()))
 		}
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := filterChangedFiles(files, changed)
+	if !checkQuiet {
+		cmd.PrintErrf("%d of %d configuration file(s) changed since %s\n", len(res), len(files), checkChangedBase)
+	}
+	return res, nil
+}
 
-		if compilationErr != nil {
-			cmd.Println(errorMsg("ERROR compiling the schema:") + fmt.Sprintf("\t%s\n", compilationErr.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+// runChecks checks the files with the options of the flags and writes the results. The files
+// not checked yet are skipped once the context is done
+func runChecks(ctx context.Context, cmd *cobra.Command, files []string, stdinSchema []byte) error {
+	results := make([]CheckResult, 0, len(files))
+	failed := 0
+	schemaCache := &SchemaCache{}
+	newOptions := func(file string) CheckOptions {
+		opts := checkOptionsFromFlags(cmd, file)
+		opts.SchemaCache = schemaCache
+		if checkConfigInline != "" {
+			opts.ConfigFile, opts.ConfigContent = "", []byte(checkConfigInline)
 		}
+		if stdinSchema != nil {
+			opts.Stdin = bytes.NewReader(stdinSchema)
+		}
+		return opts
+	}
+	// the routers tested listen on the same ports, so those checks run one at a time
+	jobs := checkJobs
+	if checkGinRoutes || checkListRoutes {
+		jobs = 1
+	}
 
-		if err = sch.Validate(raw); err != nil {
-			cmd.Println(errorMsg("ERROR linting the configuration file:") + fmt.Sprintf("\t%s\n", err.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+	start := time.Now()
+	err := checkFiles(ctx, cmd, files, jobs, newOptions, func(res CheckResult) {
+		if len(res.Errors) > 0 {
+			failed++
 		}
+		results = append(results, res)
+	})
+	if err != nil {
+		return checkUsageError(cmd, "ERROR checking the configuration:", err)
+	}
+
+	if len(files) > 1 && !checkStructuredStdout() && (!checkQuiet || failed > 0) {
+		printCheckSummary(cmd, results)
+	}
+	if len(files) > 1 && checkTimings {
+		cmd.PrintErrf("%d file(s) checked in %s\n", len(files), time.Since(start).Round(time.Microsecond))
 	}
 
-	if checkDebug > 0 {
-		cc := dumper.NewWithColors(cmd, checkDumpPrefix, checkDebug, IsTTY)
-		if err := cc.Dump(v); err != nil {
-			cmd.Println(errorMsg("ERROR checking the configuration file:") + fmt.Sprintf("\t%s\n", err.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+	written := writeCheckResults(cmd, checkOutputFormat, results)
+	if ctx.Err() != nil {
+		if skipped := len(files) - len(results); skipped > 0 {
+			cmd.PrintErrln(errorMsg("ERROR the check was aborted:") + fmt.Sprintf("\t%d of %d file(s) not checked\n", skipped, len(files)))
 		}
+		return &ExitError{Code: ExitCodeAborted}
+	}
+	if failed > 0 && checkExitZero {
+		cmd.PrintErrln(warnMsg(fmt.Sprintf("WARNING %d failed configuration(s) reported with exit code 0 due to --exit-zero", failed)))
+	} else if failed > 0 {
+		return &ExitError{Code: checkExitCode(results)}
 	}
+	if !written {
+		return &ExitError{Code: ExitCodeFailure}
+	}
+	return nil
+}
 
-	if checkGinRoutes {
-		if err := RunRouterFunc(v); err != nil {
-			cmd.Println(errorMsg("ERROR testing the configuration file:") + fmt.Sprintf("\t%s\n", err.Error()))
-			os.Exit(1) // skipcq: RVV-A0003
-			return
+func isSupportedFormat(format string, supported []string) bool {
+	for _, f := range supported {
+		if f == format {
+			return true
 		}
 	}
+	return false
+}
+
+// lintFlags are the flags enabling or tuning the linting
+var lintFlags = []string{"lint", "lint-no-network", "lint-schema", "schema-version", "strict", "fragment", "explain", "lint-ignore", "no-auto-schema"}
 
-	if IsTTY {
-		cmd.Printf("%sSyntax OK!%s\n", dumper.ColorGreen, dumper.ColorReset)
-		return
+// noLintIgnoredFlags returns the lint flags passed along with --no-lint, as they are ignored
+func noLintIgnoredFlags(cmd *cobra.Command) []string {
+	var ignored []string
+	for _, name := range lintFlags {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			ignored = append(ignored, "--"+name)
+		}
+	}
+	return ignored
+}
+
+// checkStructuredStdout tells if the structured results are written to stdout. Otherwise, stdout
+// is available for the summary and the dumps, as the messages of the checks go to stderr
+func checkStructuredStdout() bool {
+	return checkOutputFormat != formatText && checkReportFile == ""
+}
+
+// checkUsageError reports a wrong usage of the command
+func checkUsageError(cmd *cobra.Command, title string, err error) error {
+	r := newCheckReporter(cmd.ErrOrStderr(), UseColors())
+	r.fail(stageUsage, "", title, err)
+	writeCheckResults(cmd, checkOutputFormat, []CheckResult{r.result})
+	return &ExitError{Code: ExitCodeUsage}
+}
+
+// expandConfigFiles resolves the glob patterns in the received list of paths. Paths without
+// patterns (including "-" for the standard input) are returned as they are, so the parser can
+// report them if they do not exist
+func expandConfigFiles(paths []string) ([]string, error) {
+	var files []string
+	stdin := 0
+	for _, p := range paths {
+		if p == stdinConfig {
+			if stdin++; stdin > 1 {
+				return nil, fmt.Errorf("the standard input (%s) can only be used once", stdinConfig)
+			}
+		}
+		if isConfigURL(p) || !strings.ContainsAny(p, "*?[") {
+			files = append(files, p)
+			continue
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no configuration file matches the pattern %q", p)
+		}
+		files = append(files, matches...)
 	}
-	cmd.Println("Syntax OK!")
+	return files, nil
+}
+
+var RunRouterFunc = func(cfg config.ServiceConfig) error {
+	_, err := runRouter(cfg)
+	return err
 }
 
-var RunRouterFunc = func(cfg config.ServiceConfig) (err error) {
+// runRouter starts a gin router with the configuration until the run timeout expires and
+// returns the routes registered in the engine. With --build-only, the routes are registered
+// but the server never listens
+func runRouter(cfg config.ServiceConfig) (routes gin.RoutesInfo, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = errors.New(r.(string))
+			err = panicError(r, checkDebug > 0)
 		}
 	}()
 
@@ -165,38 +1279,160 @@ var RunRouterFunc = func(cfg config.ServiceConfig) (err error) {
 		cfg.Port = port
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	krakendgin.DefaultFactory(proxy.DefaultFactory(logging.NoOp), logging.NoOp).NewWithContext(ctx).Run(cfg)
+	runServer := krakendgin.RunServerFunc(server.RunServer)
+	if checkBuildOnly {
+		// the router registers the endpoints before running the server
+		runServer = func(context.Context, config.ServiceConfig, http.Handler) error { return nil }
+	}
+
+	engine := gin.Default()
+	factory := krakendgin.NewFactory(krakendgin.Config{
+		Engine:         engine,
+		Middlewares:    []gin.HandlerFunc{},
+		HandlerFactory: krakendgin.EndpointHandler,
+		ProxyFactory:   proxy.DefaultFactory(logging.NoOp),
+		Logger:         logging.NoOp,
+		RunServer:      runServer,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	factory.NewWithContext(ctx).Run(cfg)
 	cancel()
-	return nil
+	return engine.Routes(), nil
+}
+
+// runCancelable runs f until it returns or the context is done. The router can not be stopped
+// from outside, so a cancelled f keeps running in the background. Its panics are propagated
+func runCancelable(ctx context.Context, f func() error) error {
+	type outcome struct {
+		err      error
+		panicked interface{}
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{panicked: p}
+			}
+		}()
+		done <- outcome{err: f()}
+	}()
+	select {
+	case o := <-done:
+		if o.panicked != nil {
+			panic(o.panicked)
+		}
+		return o.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func getVersionMinor(ver string) string {
-	comps := strings.Split(ver, ".")
-	if len(comps) < 2 {
-		return ver
+// freePort returns a TCP port available in the moment of the call
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
 	}
-	return fmt.Sprintf("%s.%s", comps[0], comps[1])
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
-type SchemaHttpLoader http.Client
+// panicError converts a recovered value into an error, adding the stack of the panicking
+// goroutine if required. It must be called from the deferred function
+func panicError(r interface{}, withStack bool) error {
+	msg := fmt.Sprintf("%v", r)
+	if withStack {
+		msg += "\n" + string(runtimedebug.Stack())
+	}
+	return errors.New(msg)
+}
 
-func (l *SchemaHttpLoader) Load(url string) (interface{}, error) {
-	client := (*http.Client)(l)
-	resp, err := client.Get(url)
+var schemaVersionPattern = regexp.MustCompile(`^\d+\.\d+$`)
+
+// invalidSchemaVersion is the usage error of a pinned schema version not in the MAJOR.MINOR
+// format, which would end in the URL and the cache path of the schema
+func invalidSchemaVersion(version string) error {
+	return &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf("invalid schema version %q. Use the MAJOR.MINOR format, like 2.6", version)}
+}
+
+// onlineSchemaURL returns the URL of the official online schema to validate against, served from
+// the base URL when set
+func onlineSchemaURL(baseURL, pinned string) (string, error) {
+	tmpl, err := schemaURLTemplate(baseURL)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	if resp.StatusCode != http.StatusOK {
-		_ = resp.Body.Close()
-		return nil, fmt.Errorf("%s returned status code %d", url, resp.StatusCode)
+	version, err := onlineSchemaVersion(pinned)
+	if err != nil {
+		return "", err
 	}
+	return fmt.Sprintf(tmpl, version), nil
+}
 
-	body, err := jsonschema.UnmarshalJSON(resp.Body)
+// schemaURLTemplate returns the template of the online schema URL for the base URL. The base
+// can declare where the version goes with a %s placeholder. Otherwise, the versioned path of the
+// official schema is appended to it
+func schemaURLTemplate(baseURL string) (string, error) {
+	if baseURL == "" {
+		return SchemaURL, nil
+	}
+	tmpl := baseURL
+	switch placeholders := strings.Count(baseURL, "%"); {
+	case placeholders == 0:
+		tmpl = strings.TrimSuffix(baseURL, "/") + "/v%s/krakend.json"
+	case placeholders > 1 || !strings.Contains(baseURL, "%s"):
+		return "", fmt.Errorf("invalid schema base URL %q. It can only contain a %%s placeholder for the version", baseURL)
+	}
+	if u, err := url.Parse(fmt.Sprintf(tmpl, "2.6")); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid schema base URL %q. It must be an http or https URL", baseURL)
+	}
+	return tmpl, nil
+}
+
+// onlineSchemaVersion returns the MAJOR.MINOR version of the online schema to validate against.
+// The pinned version takes precedence over the version of the binary
+func onlineSchemaVersion(pinned string) (string, error) {
+	if pinned != "" {
+		if !schemaVersionPattern.MatchString(pinned) {
+			return "", invalidSchemaVersion(pinned)
+		}
+		return pinned, nil
+	}
+	return getVersionMinor(core.KrakendVersion)
+}
+
+var schemaIDVersionPattern = regexp.MustCompile(`/v?(\d+\.\d+)/`)
+
+// staleEmbeddedSchema returns the version declared by the $id of the embedded schema and true
+// when it differs from the MAJOR.MINOR of the binary. Unversioned schemas and binaries are never stale
+func staleEmbeddedSchema(rawSchema, binaryVersion string) (string, bool) {
+	var doc struct {
+		ID string `json:"$id"`
+	}
+	if err := json.Unmarshal([]byte(rawSchema), &doc); err != nil {
+		return "", false
+	}
+	m := schemaIDVersionPattern.FindStringSubmatch(doc.ID)
+	if m == nil {
+		return "", false
+	}
+	binary, err := getVersionMinor(binaryVersion)
 	if err != nil {
-		resp.Body.Close()
-		return nil, err
+		return "", false
 	}
+	return m[1], m[1] != binary
+}
 
-	return body, resp.Body.Close()
+// getVersionMinor extracts the MAJOR.MINOR part of a version, ignoring its leading v. The versions
+// of the custom builds (like dev or a git hash) have no online schema, so they must be pinned with
+// --schema-version
+func getVersionMinor(ver string) (string, error) {
+	comps := strings.Split(strings.TrimPrefix(ver, "v"), ".")
+	if len(comps) >= 2 {
+		if minor := comps[0] + "." + comps[1]; schemaVersionPattern.MatchString(minor) {
+			return minor, nil
+		}
+	}
+	return "", fmt.Errorf("the version %q of this binary has no online schema. Pin one with --schema-version, like 2.6", ver)
 }
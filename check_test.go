@@ -0,0 +1,1544 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/core"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+type parserFunc func(string) (config.ServiceConfig, error)
+
+func (f parserFunc) Parse(path string) (config.ServiceConfig, error) {
+	return f(path)
+}
+
+// jsonParser is a minimal parser decoding the few fields required by the tests
+var jsonParser = parserFunc(func(path string) (config.ServiceConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return config.ServiceConfig{}, err
+	}
+	var cfg struct {
+		Name    string `json:"name"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return config.ServiceConfig{}, err
+	}
+	return config.ServiceConfig{Name: cfg.Name, Version: cfg.Version}, nil
+})
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "krakend.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_checkFunc(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	invalidCfg := writeTestConfig(t, `{"version": 2, "name": "test", "endpoints": [{"method": "GETX"}]}`)
+
+	tests := map[string]struct {
+		files     []string
+		parser    config.Parser
+		lint      bool
+		routes    bool
+		routesErr error
+		stage     string
+		code      int
+	}{
+		"ok": {
+			files:  []string{validCfg},
+			parser: jsonParser,
+			lint:   true,
+			routes: true,
+		},
+		"missing config": {
+			parser: jsonParser,
+			stage:  stageUsage,
+			code:   ExitCodeUsage,
+		},
+		"parse error": {
+			files: []string{validCfg},
+			parser: parserFunc(func(string) (config.ServiceConfig, error) {
+				return config.ServiceConfig{}, errors.New("boom")
+			}),
+			stage: stageParse,
+			code:  ExitCodeParse,
+		},
+		"lint error": {
+			files:  []string{invalidCfg},
+			parser: jsonParser,
+			lint:   true,
+			stage:  stageLint,
+			code:   ExitCodeLint,
+		},
+		"route error": {
+			files:     []string{validCfg},
+			parser:    jsonParser,
+			routes:    true,
+			routesErr: errors.New("duplicated route"),
+			stage:     stageRoutes,
+			code:      ExitCodeRoutes,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			origParser, origRouter := parser, RunRouterFunc
+			origFiles, origFormat, origLint, origRoutes, origSchema := checkConfigFiles, checkOutputFormat, lintNoNetwork, checkGinRoutes, rawEmbedSchema
+			defer func() {
+				parser, RunRouterFunc = origParser, origRouter
+				checkConfigFiles, checkOutputFormat, lintNoNetwork, checkGinRoutes, rawEmbedSchema = origFiles, origFormat, origLint, origRoutes, origSchema
+			}()
+
+			parser = tc.parser
+			RunRouterFunc = func(config.ServiceConfig) error { return tc.routesErr }
+			checkConfigFiles = tc.files
+			checkOutputFormat = formatJSON
+			lintNoNetwork = tc.lint
+			checkGinRoutes = tc.routes
+			rawEmbedSchema = testSchema
+
+			var stdout, stderr bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+
+			err := checkFunc(cmd, nil)
+
+			var results []CheckResult
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &results), stdout.String())
+			require.Len(t, results, 1)
+			res := results[0]
+
+			if tc.stage == "" {
+				require.NoError(t, err)
+				require.Contains(t, stderr.String(), "Syntax OK!")
+				require.Empty(t, res.Errors)
+				require.Equal(t, tc.lint, res.LintPassed)
+				require.Equal(t, tc.routes, res.RoutesTested)
+				return
+			}
+
+			var exitErr *ExitError
+			require.ErrorAs(t, err, &exitErr)
+			require.Equal(t, tc.code, exitErr.Code)
+			require.NotEmpty(t, res.Errors)
+			for _, e := range res.Errors {
+				require.Equal(t, tc.stage, e.Stage)
+			}
+		})
+	}
+}
+
+func Test_checkFunc_reportFile(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	reportFile := filepath.Join(t.TempDir(), "reports", "krakend", "check.json")
+
+	origParser := parser
+	origFiles, origFormat, origQuiet, origReport := checkConfigFiles, checkOutputFormat, checkQuiet, checkReportFile
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkOutputFormat, checkQuiet, checkReportFile = origFiles, origFormat, origQuiet, origReport
+	}()
+	parser = jsonParser
+	checkConfigFiles = []string{validCfg}
+	checkOutputFormat = formatJSON
+	checkQuiet = true
+	checkReportFile = reportFile
+
+	var stdout, stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	require.NoError(t, checkFunc(cmd, nil))
+	require.Empty(t, stdout.String())
+	require.Empty(t, stderr.String())
+
+	b, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var results []CheckResult
+	require.NoError(t, json.Unmarshal(b, &results))
+	require.Len(t, results, 1)
+	res := results[0]
+	require.Equal(t, validCfg, res.ConfigFile)
+	require.Empty(t, res.Errors)
+}
+
+func Test_checkFunc_severalFiles(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	invalidCfg := writeTestConfig(t, `{"version": 2, "name": "test"}`)
+
+	origParser := parser
+	origFiles, origFormat, origLint, origSchema := checkConfigFiles, checkOutputFormat, lintNoNetwork, rawEmbedSchema
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkOutputFormat, lintNoNetwork, rawEmbedSchema = origFiles, origFormat, origLint, origSchema
+	}()
+	parser = jsonParser
+	checkConfigFiles = []string{validCfg, invalidCfg}
+	checkOutputFormat = formatJSON
+	lintNoNetwork = true
+	rawEmbedSchema = testSchema
+
+	var stdout, stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, checkFunc(cmd, nil), &exitErr)
+
+	var res []CheckResult
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &res), stdout.String())
+	require.Len(t, res, 2)
+	require.Equal(t, validCfg, res[0].ConfigFile)
+	require.Empty(t, res[0].Errors)
+	require.Equal(t, invalidCfg, res[1].ConfigFile)
+	require.NotEmpty(t, res[1].Errors)
+}
+
+func Test_checkFunc_runTimeout(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	origParser, origRouter := parser, RunRouterFunc
+	origFiles, origFormat, origRoutes, origTimeout := checkConfigFiles, checkOutputFormat, checkGinRoutes, runTimeout
+	defer func() {
+		parser, RunRouterFunc = origParser, origRouter
+		checkConfigFiles, checkOutputFormat, checkGinRoutes, runTimeout = origFiles, origFormat, origRoutes, origTimeout
+	}()
+	parser = jsonParser
+	RunRouterFunc = func(config.ServiceConfig) error { return nil }
+	checkConfigFiles = []string{validCfg}
+	checkOutputFormat = formatJSON
+	checkGinRoutes = true
+
+	for timeout, code := range map[time.Duration]int{0: ExitCodeUsage, -time.Second: ExitCodeUsage, 5 * time.Second: 0} {
+		runTimeout = timeout
+		var stdout, stderr bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+
+		err := checkFunc(cmd, nil)
+		if code == 0 {
+			require.NoError(t, err, timeout)
+			continue
+		}
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr, timeout)
+		require.Equal(t, code, exitErr.Code, timeout)
+		require.Contains(t, stdout.String(), "invalid run timeout", timeout)
+	}
+}
+
+func Test_checkFunc_quietWarnings(t *testing.T) {
+	origVersion := core.KrakendVersion
+	defer func() { core.KrakendVersion = origVersion }()
+	core.KrakendVersion = "2.7.1"
+
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test", "cache_ttl": "3s"}`)
+	origParser := parser
+	origFiles, origFormat, origQuiet, origLint, origSchema := checkConfigFiles, checkOutputFormat, checkQuiet, lintNoNetwork, rawEmbedSchema
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkOutputFormat, checkQuiet, lintNoNetwork, rawEmbedSchema = origFiles, origFormat, origQuiet, origLint, origSchema
+	}()
+	// the configuration has a deprecated key, a repeated host, an inverted timeout and is
+	// linted against a stale embedded schema: all of them warnings
+	parser = parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{Version: 3, Timeout: time.Second, Endpoints: []*config.EndpointConfig{{
+			Endpoint: "/a",
+			Method:   "GET",
+			Timeout:  2 * time.Second,
+			Backend:  []*config.Backend{{Host: []string{"http://a", "http://a"}}},
+		}}}, nil
+	})
+	checkConfigFiles = []string{cfg}
+	checkOutputFormat = formatText
+	lintNoNetwork = true
+	rawEmbedSchema = `{"$id": "https://www.krakend.io/schema/v2.6/krakend.json",` + strings.TrimPrefix(testSchema, "{")
+
+	for _, quiet := range []bool{false, true} {
+		checkQuiet = quiet
+		var stdout, stderr bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		require.NoError(t, checkFunc(cmd, nil))
+		if quiet {
+			require.Empty(t, stderr.String())
+			continue
+		}
+		for _, title := range []string{"WARNING linting", "WARNING checking the embedded schema", "WARNING validating the backend hosts", "WARNING checking the timeouts"} {
+			require.Contains(t, stderr.String(), title)
+		}
+	}
+}
+
+func Test_expandConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "c.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(`{}`), 0o600))
+	}
+
+	files, err := expandConfigFiles([]string{filepath.Join(dir, "*.json"), "missing.json", "https://example.com/krakend.json"})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), "missing.json", "https://example.com/krakend.json"}, files)
+
+	_, err = expandConfigFiles([]string{filepath.Join(dir, "*.toml")})
+	require.ErrorContains(t, err, "no configuration file matches the pattern")
+
+	_, err = expandConfigFiles([]string{"[.json"})
+	require.ErrorContains(t, err, "invalid pattern")
+
+	_, err = expandConfigFiles([]string{stdinConfig, stdinConfig})
+	require.ErrorContains(t, err, "can only be used once")
+}
+
+func TestCheck(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	_, err := Check(CheckOptions{Parser: jsonParser})
+	require.ErrorContains(t, err, "the path to the configuration file is required")
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out})
+	require.NoError(t, err)
+	require.Equal(t, validCfg, res.ConfigFile)
+	require.Empty(t, res.Errors)
+	require.Contains(t, out.String(), "Parsing configuration file: "+validCfg)
+	require.Contains(t, out.String(), "Syntax OK!")
+
+	// the failures of the checks are reported in the result, not as errors
+	res, err = Check(CheckOptions{ConfigFile: validCfg, Parser: parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{}, errors.New("boom")
+	})})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageParse, res.Errors[0].Stage)
+
+	origParser := parser
+	defer func() { parser = origParser }()
+	var parsed string
+	parser = parserFunc(func(path string) (config.ServiceConfig, error) {
+		parsed = path
+		return jsonParser(path)
+	})
+	res, err = Check(CheckOptions{ConfigFile: validCfg})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, validCfg, parsed)
+}
+
+func TestCheck_configContent(t *testing.T) {
+	res, err := Check(CheckOptions{
+		ConfigContent:  []byte(`{"version": 3, "name": 42}`),
+		Parser:         jsonParser,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "inline", res.ConfigFile)
+	require.False(t, res.LintPassed)
+	require.NotEmpty(t, res.Errors)
+	require.Equal(t, "inline", res.Errors[0].Source)
+
+	res, err = Check(CheckOptions{ConfigContent: []byte(`{"version": 3}`), Parser: jsonParser})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+}
+
+func TestCheck_raw(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	failingParser := parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{}, errors.New("the parser must not be used")
+	})
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: failingParser, Raw: true, LintNoNetwork: true, EmbeddedSchema: testSchema, TestGinRoutes: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.False(t, res.RoutesTested)
+
+	res, err = Check(CheckOptions{ConfigContent: []byte(`{"version": 3, "name": 42}`), Parser: failingParser, Raw: true, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.False(t, res.LintPassed)
+	require.NotEmpty(t, res.Errors)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, Raw: true})
+	require.ErrorContains(t, err, "requires a schema")
+}
+
+func TestCheck_tolerantJSON(t *testing.T) {
+	cfg := writeTestConfig(t, "\xef\xbb\xbf{\"version\": 3, \"name\": \"test\",}")
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Raw: true, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Errors)
+	require.Equal(t, stageLoad, res.Errors[0].Stage)
+
+	var out bytes.Buffer
+	res, err = Check(CheckOptions{ConfigFile: cfg, Output: &out, Raw: true, LintNoNetwork: true, EmbeddedSchema: testSchema, TolerantJSON: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.Len(t, res.Warnings, 1)
+	require.Contains(t, out.String(), "0 comment(s) and 1 trailing comma(s) removed before linting")
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, TolerantJSON: true})
+	require.ErrorContains(t, err, "requires a schema")
+}
+
+func TestCheck_quiet(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out, Quiet: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Empty(t, out.String())
+
+	res, err = Check(CheckOptions{
+		ConfigFile: validCfg,
+		Parser: parserFunc(func(string) (config.ServiceConfig, error) {
+			return config.ServiceConfig{}, errors.New("boom")
+		}),
+		Output: &out,
+		Quiet:  true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Errors)
+	require.Contains(t, out.String(), "boom")
+	require.NotContains(t, out.String(), "Parsing configuration file")
+}
+
+func TestCheck_verbose(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	for level, expected := range map[int]bool{0: false, 1: true} {
+		var out bytes.Buffer
+		_, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out, Verbosity: level})
+		require.NoError(t, err)
+		require.Equal(t, expected, bytes.Contains(out.Bytes(), []byte("Configuration parsed in")), out.String())
+	}
+}
+
+func TestCheck_toml(t *testing.T) {
+	noopParser := parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{}, nil
+	})
+
+	for file, locations := range map[string][]string{
+		"testdata/lint/valid.toml":   nil,
+		"testdata/lint/invalid.toml": {"/endpoints/0/method", "/version"},
+	} {
+		res, err := Check(CheckOptions{
+			ConfigFile:     file,
+			Parser:         noopParser,
+			LintNoNetwork:  true,
+			EmbeddedSchema: testSchema,
+		})
+		require.NoError(t, err)
+		require.Equal(t, locations == nil, res.LintPassed, file)
+
+		var found []string
+		for _, e := range res.Errors {
+			require.Equal(t, stageLint, e.Stage)
+			found = append(found, e.Location)
+		}
+		require.Equal(t, locations, found, file)
+	}
+}
+
+func TestCheck_jsonDump(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	var dump bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, DumpFormat: formatJSON, DumpOutput: &dump})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+
+	var cfg map[string]interface{}
+	require.NoError(t, json.Unmarshal(dump.Bytes(), &cfg), dump.String())
+	require.Equal(t, map[string]interface{}{"name": "test", "version": float64(3)}, cfg)
+
+	_, err = Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, DumpFormat: "xml"})
+	require.Error(t, err)
+}
+
+func TestCheck_dumpOnly(t *testing.T) {
+	invalidCfg := writeTestConfig(t, `{"version": 2, "name": "test"}`)
+
+	origRouter := RunRouterFunc
+	defer func() { RunRouterFunc = origRouter }()
+	RunRouterFunc = func(config.ServiceConfig) error { return errors.New("routes should not be tested") }
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{
+		ConfigFile:     invalidCfg,
+		Parser:         jsonParser,
+		Output:         &out,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		TestGinRoutes:  true,
+		DumpOnly:       true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.False(t, res.LintPassed)
+	require.False(t, res.RoutesTested)
+	require.Contains(t, out.String(), "test")
+}
+
+func Test_panicError(t *testing.T) {
+	recovered := func(v interface{}, withStack bool) (err error) {
+		defer func() {
+			err = panicError(recover(), withStack)
+		}()
+		panic(v)
+	}
+
+	require.EqualError(t, recovered("boom", false), "boom")
+	require.EqualError(t, recovered(42, false), "42")
+	require.EqualError(t, recovered(errors.New("wrapped"), false), "wrapped")
+
+	err := recovered("boom", true)
+	require.Contains(t, err.Error(), "boom\ngoroutine")
+	require.Contains(t, err.Error(), "Test_panicError")
+}
+
+func Test_getVersionMinor(t *testing.T) {
+	for ver, expected := range map[string]string{
+		"2.6.1":   "2.6",
+		"2.6.0":   "2.6",
+		"2.6":     "2.6",
+		"v2.6.0":  "2.6",
+		"v2.6":    "2.6",
+		"2":       "",
+		"dev":     "",
+		"3f2a9c1": "",
+	} {
+		minor, err := getVersionMinor(ver)
+		if expected == "" {
+			require.ErrorContains(t, err, "--schema-version", ver)
+			continue
+		}
+		require.NoError(t, err, ver)
+		require.Equal(t, expected, minor, ver)
+	}
+}
+
+func Test_onlineSchemaURL(t *testing.T) {
+	for base, expected := range map[string]string{
+		"":                                    "https://www.krakend.io/schema/v2.6/krakend.json",
+		"https://mirror.example.com/schemas":  "https://mirror.example.com/schemas/v2.6/krakend.json",
+		"https://mirror.example.com/schemas/": "https://mirror.example.com/schemas/v2.6/krakend.json",
+		"http://mirror:8080/krakend-%s.json":  "http://mirror:8080/krakend-2.6.json",
+		"https://mirror/%s/%s.json":           "",
+		"https://mirror/%d.json":              "",
+		"mirror.example.com/schemas":          "",
+		"file:///schemas":                     "",
+	} {
+		u, err := onlineSchemaURL(base, "2.6")
+		if expected == "" {
+			require.ErrorContains(t, err, "invalid schema base URL", base)
+			continue
+		}
+		require.NoError(t, err, base)
+		require.Equal(t, expected, u, base)
+	}
+
+	for _, version := range []string{"../x", "2.6/../../x", "v2.6", "2"} {
+		_, err := onlineSchemaURL("", version)
+		var exitErr *ExitError
+		require.ErrorAs(t, err, &exitErr, version)
+		require.Equal(t, ExitCodeUsage, exitErr.Code, version)
+		require.ErrorContains(t, err, "invalid schema version", version)
+	}
+}
+
+func TestCheck_listRoutes(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	origList := ListRoutesFunc
+	defer func() { ListRoutesFunc = origList }()
+	ListRoutesFunc = func(config.ServiceConfig) ([]RouteInfo, error) {
+		return []RouteInfo{{Method: "GET", Path: "/foo/:id", Backends: []string{"http://a", "http://b"}}}, nil
+	}
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out, ListRoutes: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.RoutesTested)
+	require.Equal(t, []RouteInfo{{Method: "GET", Path: "/foo/:id", Backends: []string{"http://a", "http://b"}}}, res.Routes)
+	require.Contains(t, out.String(), "\tGET\t/foo/:id\t-> http://a, http://b\n")
+}
+
+func TestCheck_routesFreePort(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	origRouter := RunRouterFunc
+	defer func() { RunRouterFunc = origRouter }()
+	var testedPort int
+	RunRouterFunc = func(cfg config.ServiceConfig) error {
+		testedPort = cfg.Port
+		return nil
+	}
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out, Verbosity: 1, TestGinRoutes: true, RoutesFreePort: true})
+	require.NoError(t, err)
+	require.True(t, res.RoutesTested)
+	require.NotZero(t, testedPort)
+	require.Contains(t, out.String(), fmt.Sprintf("Testing the routes on the free port %d", testedPort))
+
+	_, err = Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, TestGinRoutes: true, RoutesPort: 8081})
+	require.NoError(t, err)
+	require.Equal(t, 8081, testedPort)
+}
+
+func TestCheck_aborted(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	origRouter := RunRouterFunc
+	defer func() { RunRouterFunc = origRouter }()
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	RunRouterFunc = func(config.ServiceConfig) error {
+		// the router hangs until the user interrupts the check
+		cancel()
+		<-release
+		return nil
+	}
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, Output: &out, TestGinRoutes: true, Context: ctx})
+	require.NoError(t, err)
+	require.False(t, res.RoutesTested)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageAborted, res.Errors[0].Stage)
+	require.Equal(t, ExitCodeAborted, checkExitCode([]CheckResult{{Errors: []CheckError{{Stage: stageLint}}}, res}))
+	require.Contains(t, out.String(), "ERROR the check was aborted:\t"+validCfg+": context canceled")
+
+	// a done context skips the whole check
+	res, err = Check(CheckOptions{ConfigFile: validCfg, Parser: parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{}, errors.New("the configuration should not be parsed")
+	}), Context: ctx})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageAborted, res.Errors[0].Stage)
+}
+
+func TestCheck_continueOnError(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 2, "name": "test"}`)
+
+	origRouter := RunRouterFunc
+	defer func() { RunRouterFunc = origRouter }()
+	RunRouterFunc = func(config.ServiceConfig) error { return errors.New("duplicated route") }
+
+	opts := CheckOptions{
+		ConfigFile:     cfg,
+		Parser:         jsonParser,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		TestGinRoutes:  true,
+	}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+
+	opts.ContinueOnError = true
+	var out bytes.Buffer
+	opts.Output = &out
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 2)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+	require.Equal(t, stageRoutes, res.Errors[1].Stage)
+	require.NotContains(t, out.String(), "Syntax OK!")
+}
+
+func TestCheck_timings(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{
+		ConfigFile:     cfg,
+		Parser:         jsonParser,
+		Output:         &out,
+		Quiet:          true,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		Timings:        true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+
+	phases := make([]string, len(res.Timings))
+	for i, timing := range res.Timings {
+		phases[i] = timing.Phase
+	}
+	require.Equal(t, []string{phaseParse, phaseSchemaFetch, phaseSchemaCompile, phaseValidate}, phases)
+	require.Contains(t, out.String(), "Timings:\n")
+	require.Contains(t, out.String(), "\tcompile schema  ")
+}
+
+func TestCheck_stdinSchema(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	invalidCfg := writeTestConfig(t, `{"version": 2, "name": "test"}`)
+
+	opts := CheckOptions{
+		ConfigFile:   validCfg,
+		Parser:       jsonParser,
+		SchemaPath:   stdinConfig,
+		Stdin:        strings.NewReader(testSchema),
+		SchemaLoader: SchemaLoaderOptions{Timeout: time.Second},
+	}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.True(t, res.LintPassed)
+	require.Equal(t, stdinConfig, res.SchemaUsed)
+
+	opts.ConfigFile, opts.Stdin = invalidCfg, strings.NewReader(testSchema)
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.False(t, res.LintPassed)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+
+	opts.ConfigFile = stdinConfig
+	_, err = Check(opts)
+	require.ErrorContains(t, err, "can only be used once")
+}
+
+func TestCheck_schemaBaseURI(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	defsDir, schemaDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(defsDir, "defs.json"), []byte(`{"$defs": {"version": {"const": 3}}}`), 0o600))
+	mainSchema := `{"properties": {"version": {"$ref": "defs.json#/$defs/version"}}}`
+	schemaPath := filepath.Join(schemaDir, "main.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(mainSchema), 0o600))
+
+	opts := CheckOptions{
+		ConfigFile:   cfg,
+		Parser:       jsonParser,
+		SchemaPath:   schemaPath,
+		SchemaLoader: SchemaLoaderOptions{Timeout: time.Second},
+	}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.Equal(t, stageSchema, res.Errors[0].Stage)
+
+	opts.SchemaBaseURI = defsDir
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+
+	opts.SchemaPath, opts.Stdin = stdinConfig, strings.NewReader(mainSchema)
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+}
+
+func TestCheck_schemaCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var downloads int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		w.Write([]byte(testSchema))
+	}))
+	defer s.Close()
+
+	files := []string{
+		writeTestConfig(t, `{"version": 3, "name": "a"}`),
+		writeTestConfig(t, `{"version": 3, "name": "b"}`),
+		writeTestConfig(t, `{"version": 2, "name": "c"}`),
+	}
+	check := func(cache *SchemaCache) []CheckResult {
+		results := make([]CheckResult, len(files))
+		for i, file := range files {
+			res, err := Check(CheckOptions{
+				ConfigFile:   file,
+				Parser:       jsonParser,
+				SchemaPath:   s.URL + "/schema.json",
+				SchemaLoader: SchemaLoaderOptions{Timeout: time.Second, NoCache: true},
+				SchemaCache:  cache,
+			})
+			require.NoError(t, err)
+			results[i] = res
+		}
+		return results
+	}
+
+	uncached := check(nil)
+	require.Equal(t, int32(len(files)), atomic.SwapInt32(&downloads, 0))
+
+	cached := check(&SchemaCache{})
+	require.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	require.Equal(t, uncached, cached)
+	require.False(t, cached[2].LintPassed)
+}
+
+func TestCheck_schemaVersion(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var path string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		w.Write([]byte(testSchema))
+	}))
+	defer s.Close()
+
+	opts := CheckOptions{
+		ConfigFile:    writeTestConfig(t, `{"version": 2, "name": "test"}`),
+		Parser:        jsonParser,
+		SchemaVersion: "2.4",
+		SchemaBaseURL: s.URL,
+		SchemaLoader:  SchemaLoaderOptions{Timeout: time.Second, NoCache: true},
+	}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.Equal(t, "/v2.4/krakend.json", path)
+	require.Equal(t, s.URL+"/v2.4/krakend.json", res.SchemaUsed)
+	require.False(t, res.LintPassed)
+	require.NotEmpty(t, res.Errors)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+
+	opts.SchemaVersion = "2.4.1"
+	_, err = Check(opts)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitCodeUsage, exitErr.Code)
+}
+
+func TestCheck_warnAsError(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test", "cache_ttl": "3s"}`)
+
+	for _, warnAsError := range []bool{false, true} {
+		res, err := Check(CheckOptions{
+			ConfigFile:     cfg,
+			Parser:         jsonParser,
+			LintNoNetwork:  true,
+			EmbeddedSchema: testSchema,
+			WarnAsError:    warnAsError,
+		})
+		require.NoError(t, err)
+		require.Equal(t, !warnAsError, res.LintPassed)
+		if warnAsError {
+			require.Len(t, res.Errors, 1)
+			require.Empty(t, res.Warnings)
+			require.Equal(t, "deprecated", res.Errors[0].Keyword)
+			continue
+		}
+		require.Empty(t, res.Errors)
+		require.Len(t, res.Warnings, 1)
+		require.Equal(t, "/cache_ttl", res.Warnings[0].Location)
+	}
+}
+
+func TestCheck_staleEmbeddedSchema(t *testing.T) {
+	orig := core.KrakendVersion
+	defer func() { core.KrakendVersion = orig }()
+	core.KrakendVersion = "2.7.1"
+
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	schema := `{"$id": "https://www.krakend.io/schema/v2.6/krakend.json",` + strings.TrimPrefix(testSchema, "{")
+
+	for _, warnAsError := range []bool{false, true} {
+		res, err := Check(CheckOptions{
+			ConfigFile:     cfg,
+			Parser:         jsonParser,
+			LintNoNetwork:  true,
+			EmbeddedSchema: schema,
+			WarnAsError:    warnAsError,
+		})
+		require.NoError(t, err)
+		if warnAsError {
+			require.False(t, res.LintPassed)
+			require.Len(t, res.Errors, 1)
+			require.Equal(t, stageSchema, res.Errors[0].Stage)
+			require.Contains(t, res.Errors[0].Message, "for KrakenD 2.6 but this binary is 2.7.1")
+			continue
+		}
+		require.True(t, res.LintPassed)
+		require.Empty(t, res.Errors)
+		require.Len(t, res.Warnings, 1)
+		require.Contains(t, res.Warnings[0].Message, "--lint")
+	}
+
+	core.KrakendVersion = "v2.6.3"
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, LintNoNetwork: true, EmbeddedSchema: schema})
+	require.NoError(t, err)
+	require.Empty(t, res.Warnings)
+}
+
+func TestCheck_fragment(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "endpoints": [
+		{"endpoint": "/a", "method": "GET", "backend": [{"host": ["http://a"], "url_pattern": "/"}]},
+		{"endpoint": "/b", "method": "GETX", "backend": [{"host": ["http://b"], "url_pattern": "/"}]}
+	]}`)
+	check := func(fragment string) CheckResult {
+		res, err := Check(CheckOptions{
+			ConfigFile:     cfg,
+			Parser:         jsonParser,
+			LintNoNetwork:  true,
+			EmbeddedSchema: testSchema,
+			Fragment:       fragment,
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	res := check("/endpoints/0")
+	require.True(t, res.LintPassed)
+	require.Empty(t, res.Errors)
+
+	res = check("/endpoints/1")
+	require.False(t, res.LintPassed)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "/endpoints/1/method", res.Errors[0].Location)
+	require.Equal(t, 3, res.Errors[0].Line)
+
+	res = check("/")
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "/endpoints/1/method", res.Errors[0].Location)
+
+	res = check("/endpoints/2")
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "/endpoints/2 does not exist in the configuration", res.Errors[0].Message)
+
+	_, err := Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, Fragment: "endpoints"})
+	require.ErrorContains(t, err, "invalid fragment")
+}
+
+func TestCheck_maxErrors(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "endpoints": [
+		{"endpoint": "/a", "method": "GETA", "backend": [{"host": ["http://a"], "url_pattern": "/"}]},
+		{"endpoint": "/b", "method": "GETB", "backend": [{"host": ["http://b"], "url_pattern": "/"}]},
+		{"endpoint": "/c", "method": "GETC", "backend": [{"host": ["http://c"], "url_pattern": "/"}]}
+	]}`)
+
+	out := new(bytes.Buffer)
+	res, err := Check(CheckOptions{
+		ConfigFile:     cfg,
+		Parser:         jsonParser,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		MaxErrors:      2,
+		Output:         out,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 3)
+	require.Contains(t, out.String(), "3 error(s) found")
+	require.Contains(t, out.String(), "/endpoints/1/method")
+	require.NotContains(t, out.String(), "/endpoints/2/method")
+	require.Contains(t, out.String(), "... and 1 more")
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, MaxErrors: -1})
+	require.ErrorContains(t, err, "invalid max errors")
+}
+
+func TestCheck_deprecations(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "endpoints": [{"endpoint": "/a",
+		"extra_config": {"qos/ratelimit/router": {"maxRate": 10}},
+		"backend": [{"host": ["http://a"], "url_pattern": "/"}]}]}`)
+
+	for _, warnAsError := range []bool{false, true} {
+		res, err := Check(CheckOptions{
+			ConfigFile:        cfg,
+			Parser:            config.NewParser(),
+			CheckDeprecations: true,
+			WarnAsError:       warnAsError,
+		})
+		require.NoError(t, err)
+		issues := res.Warnings
+		if warnAsError {
+			require.Empty(t, res.Warnings)
+			issues = res.Errors
+		} else {
+			require.Empty(t, res.Errors)
+		}
+		require.Len(t, issues, 1)
+		require.Equal(t, stageDeprecation, issues[0].Stage)
+		require.Equal(t, "/endpoints/0/extra_config/qos~1ratelimit~1router/maxRate", issues[0].Location)
+		require.Contains(t, issues[0].Message, "Use max_rate instead")
+	}
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: config.NewParser(), CheckDeprecations: true, TargetVersion: "1.4"})
+	require.NoError(t, err)
+	require.Empty(t, res.Warnings)
+}
+
+func TestCheck_lintIgnore(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 2, "name": "test"}`)
+	ignoreFile := filepath.Join(t.TempDir(), ".krakendignore")
+	require.NoError(t, os.WriteFile(ignoreFile, []byte("/version\n"), 0o600))
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{
+		ConfigFile:     cfg,
+		Parser:         jsonParser,
+		Output:         &out,
+		Verbosity:      1,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		LintIgnoreFile: ignoreFile,
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.Equal(t, 1, res.Ignored)
+	require.Contains(t, out.String(), "1 lint finding(s) ignored")
+}
+
+func TestCheck_layerSchemas(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	layer := filepath.Join(t.TempDir(), "conventions.json")
+	require.NoError(t, os.WriteFile(layer, []byte(`{"required": ["owner"]}`), 0o600))
+
+	opts := CheckOptions{
+		ConfigFile:     cfg,
+		Parser:         jsonParser,
+		LintNoNetwork:  true,
+		EmbeddedSchema: testSchema,
+		LayerSchemas:   []string{layer},
+		SchemaLoader:   SchemaLoaderOptions{Timeout: time.Second},
+	}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.False(t, res.LintPassed)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "required", res.Errors[0].Keyword)
+
+	opts.LayerSchemas = []string{filepath.Join(t.TempDir(), "missing.json")}
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageSchema, res.Errors[0].Stage)
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, LayerSchemas: []string{layer}})
+	require.Error(t, err)
+}
+
+type lastSourceParser struct {
+	config.Parser
+	source []byte
+}
+
+func (p lastSourceParser) LastSource() ([]byte, error) {
+	return p.source, nil
+}
+
+func TestCheck_printSource(t *testing.T) {
+	validCfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{
+		ConfigFile:   validCfg,
+		Parser:       lastSourceParser{Parser: jsonParser, source: []byte(`{"rendered": true}`)},
+		PrintSource:  true,
+		SourceOutput: &out,
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, `{"rendered": true}`, out.String())
+
+	res, err = Check(CheckOptions{ConfigFile: validCfg, Parser: jsonParser, PrintSource: true, SourceOutput: &out})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Message, "LastSourcer")
+}
+
+func Test_checkReporter_dumpFailed(t *testing.T) {
+	var out bytes.Buffer
+	r := newCheckReporter(&out, false)
+	r.dumpFailed("krakend.json", errors.Join(errors.New("first"), errors.New("second")))
+	require.Len(t, r.result.Errors, 2)
+	require.Equal(t, stageDump, r.result.Errors[1].Stage)
+	require.Equal(t, "second", r.result.Errors[1].Message)
+	require.Contains(t, out.String(), "2 error(s) found")
+	require.Contains(t, out.String(), "\tkrakend.json: second\n")
+
+	out.Reset()
+	r = newCheckReporter(&out, false)
+	r.dumpFailed("krakend.json", errors.New("single"))
+	require.Len(t, r.result.Errors, 1)
+	require.Equal(t, "single", r.result.Errors[0].Message)
+	require.Contains(t, out.String(), "\tkrakend.json: single\n")
+}
+
+func TestCheck_dumpIssues(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	p := parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{
+			Version: 3,
+			Endpoints: []*config.EndpointConfig{
+				nil,
+				{Endpoint: "/foo", Method: "FETCH", Backend: []*config.Backend{{URLPattern: "/a"}, nil}},
+			},
+		}, nil
+	})
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: p, Output: &out, DebugLevel: 1, DumpOnly: true})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 3)
+	for _, e := range res.Errors {
+		require.Equal(t, stageDump, e.Stage)
+	}
+	require.Equal(t, "endpoint 0 is not defined", res.Errors[0].Message)
+	require.Equal(t, `endpoint /foo: unknown method "FETCH"`, res.Errors[1].Message)
+	require.Equal(t, "endpoint /foo: backend 1 is not defined", res.Errors[2].Message)
+	require.Contains(t, out.String(), "3 error(s) found")
+}
+
+func TestCheck_semanticIssues(t *testing.T) {
+	certFile, _ := writeTestKeyPair(t)
+	tests := map[string]struct {
+		// content is the configuration file, when the parsed cfg is not enough
+		content string
+		cfg     config.ServiceConfig
+		opts    CheckOptions
+		// optional is set for the checks enabled by the opts
+		optional bool
+		// failed is set for the issues reported as errors even without WarnAsError
+		failed   bool
+		stage    string
+		location string
+	}{
+		"repeated backend host": {
+			cfg: config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+				{Endpoint: "/a", Method: "GET", Backend: []*config.Backend{{Host: []string{"http://a", "http://a"}}}},
+			}},
+			stage:    stageBackends,
+			location: "/endpoints/0/backend/0/host",
+		},
+		"repeated async agent backend host": {
+			cfg: config.ServiceConfig{AsyncAgents: []*config.AsyncAgent{
+				{Name: "consumer", Backend: []*config.Backend{{Host: []string{"amqp://a", "amqp://a"}}}},
+			}},
+			stage:    stageBackends,
+			location: "/async_agent/0/backend/0/host",
+		},
+		"backend without hosts": {
+			cfg: config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+				{Endpoint: "/a", Method: "GET", Backend: []*config.Backend{{}}},
+			}},
+			failed:   true,
+			stage:    stageBackends,
+			location: "/endpoints/0/backend/0/host",
+		},
+		"unknown namespace": {
+			cfg:      config.ServiceConfig{ExtraConfig: config.ExtraConfig{"securty/cors": nil}},
+			opts:     CheckOptions{CheckNamespaces: true},
+			optional: true,
+			stage:    stageNamespaces,
+			location: "/extra_config/securty~1cors",
+		},
+		"invalid tls key pair": {
+			cfg:      config.ServiceConfig{TLS: &config.TLS{PublicKey: certFile, PrivateKey: certFile + ".missing"}},
+			stage:    stageTLS,
+			location: "/tls",
+		},
+		"inverted timeout": {
+			cfg:      config.ServiceConfig{Timeout: time.Second, Endpoints: []*config.EndpointConfig{{Endpoint: "/foo", Timeout: 2 * time.Second}}},
+			stage:    stageTimeouts,
+			location: "/endpoints/0/timeout",
+		},
+		"inverted backend timeout": {
+			content: `{"version": 3, "endpoints": [{"endpoint": "/foo", "timeout": "1s", "backend": [{"timeout": "2s"}]}]}`,
+			cfg: config.ServiceConfig{Timeout: time.Second, Endpoints: []*config.EndpointConfig{
+				{Endpoint: "/foo", Timeout: time.Second, Backend: []*config.Backend{{Host: []string{"http://a"}, Timeout: time.Second}}},
+			}},
+			stage:    stageTimeouts,
+			location: "/endpoints/0/backend/0/timeout",
+		},
+	}
+
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.cfg.Version = 3
+			opts := tc.opts
+			opts.ConfigFile = cfg
+			if tc.content != "" {
+				opts.ConfigFile = writeTestConfig(t, tc.content)
+			}
+			opts.Parser = parserFunc(func(string) (config.ServiceConfig, error) { return tc.cfg, nil })
+
+			if tc.optional {
+				res, err := Check(CheckOptions{ConfigFile: opts.ConfigFile, Parser: opts.Parser})
+				require.NoError(t, err)
+				require.Empty(t, res.Warnings)
+			}
+
+			res, err := Check(opts)
+			require.NoError(t, err)
+			if tc.failed {
+				require.Len(t, res.Errors, 1)
+			} else {
+				require.Empty(t, res.Errors)
+				require.Len(t, res.Warnings, 1)
+				require.Equal(t, tc.stage, res.Warnings[0].Stage)
+				require.Equal(t, tc.location, res.Warnings[0].Location)
+			}
+
+			opts.WarnAsError = true
+			res, err = Check(opts)
+			require.NoError(t, err)
+			require.Len(t, res.Errors, 1)
+			require.Equal(t, tc.stage, res.Errors[0].Stage)
+			require.Equal(t, tc.location, res.Errors[0].Location)
+			require.Equal(t, ExitCodeLint, checkExitCode([]CheckResult{res}))
+		})
+	}
+}
+
+func Test_sourceMsg(t *testing.T) {
+	require.Equal(t, "krakend.json: boom", sourceMsg("krakend.json", "boom"))
+	require.Equal(t, "'krakend.json': boom", sourceMsg("krakend.json", "'krakend.json': boom"))
+	require.Equal(t, "boom", sourceMsg("", "boom"))
+}
+
+func TestSetParser(t *testing.T) {
+	origParser := parser
+	defer func() { parser = origParser }()
+
+	var parsed string
+	SetParser(parserFunc(func(path string) (config.ServiceConfig, error) {
+		parsed = path
+		return config.ServiceConfig{Version: 3}, nil
+	}))
+	require.NotNil(t, GetConfigParser())
+
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	res, err := Check(CheckOptions{ConfigFile: cfg})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, cfg, parsed)
+}
+
+func TestCheck_configFormat(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "krakend.conf")
+	require.NoError(t, os.WriteFile(cfg, []byte("version: 3\nname: test\n"), 0o600))
+
+	var ext string
+	p := parserFunc(func(path string) (config.ServiceConfig, error) {
+		ext = filepath.Ext(path)
+		return config.ServiceConfig{Version: 3}, nil
+	})
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, ConfigFormat: formatYAML, Parser: p, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.Equal(t, ".yaml", ext)
+	require.Equal(t, cfg, res.ConfigFile)
+
+	res, err = Check(CheckOptions{ConfigFile: stdinConfig, Stdin: strings.NewReader(`{"version": 3}`), ConfigFormat: formatYAML, Parser: p})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, ".yaml", ext)
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, ConfigFormat: "xml", Parser: p})
+	require.ErrorContains(t, err, `unknown configuration format "xml"`)
+}
+
+func Test_checkOptionsFromFlags_noLint(t *testing.T) {
+	origNoLint, origLint, origSchemas := checkNoLint, lintCurrentSchema, lintCustomSchemaPaths
+	defer func() { checkNoLint, lintCurrentSchema, lintCustomSchemaPaths = origNoLint, origLint, origSchemas }()
+	checkNoLint, lintCurrentSchema, lintCustomSchemaPaths = true, true, []string{"custom.json"}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("lint", false, "")
+	cmd.Flags().StringArray("lint-schema", nil, "")
+	cmd.Flags().Bool("strict", false, "")
+	require.NoError(t, cmd.Flags().Parse([]string{"--lint", "--lint-schema", "custom.json"}))
+
+	opts := checkOptionsFromFlags(cmd, "krakend.json")
+	require.False(t, opts.shouldLint())
+	require.Empty(t, opts.LayerSchemas)
+	require.Equal(t, []string{"--lint", "--lint-schema"}, noLintIgnoredFlags(cmd))
+}
+
+func TestCheck_configURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/krakend.json" {
+			http.NotFound(rw, req)
+			return
+		}
+		rw.Write([]byte(`{"version": 3, "name": "remote"}`))
+	}))
+	defer ts.Close()
+
+	var ext string
+	p := parserFunc(func(path string) (config.ServiceConfig, error) {
+		ext = filepath.Ext(path)
+		return jsonParser.Parse(path)
+	})
+	loader := SchemaLoaderOptions{Timeout: time.Second}
+
+	res, err := Check(CheckOptions{ConfigFile: ts.URL + "/krakend.json", Parser: p, SchemaLoader: loader, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.Equal(t, ".json", ext)
+	require.Equal(t, ts.URL+"/krakend.json", res.ConfigFile)
+
+	res, err = Check(CheckOptions{ConfigFile: ts.URL + "/missing.json", Parser: p, SchemaLoader: loader})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageLoad, res.Errors[0].Stage)
+	require.Contains(t, res.Errors[0].Message, "returned status code 404")
+
+	_, err = Check(CheckOptions{ConfigFile: ts.URL + "/krakend.json", Parser: p})
+	require.ErrorContains(t, err, "invalid schema timeout")
+}
+
+func Test_checkFunc_schemaTimeout(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(testSchema))
+	}))
+	defer s.Close()
+
+	origParser := parser
+	origFiles, origFormat, origSchemas, origTimeout, origRetries, origNoCache := checkConfigFiles, checkOutputFormat, lintCustomSchemaPaths, schemaTimeout, schemaRetries, schemaNoCache
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkOutputFormat, lintCustomSchemaPaths, schemaTimeout, schemaRetries, schemaNoCache = origFiles, origFormat, origSchemas, origTimeout, origRetries, origNoCache
+	}()
+	parser = jsonParser
+	checkConfigFiles = []string{cfg}
+	checkOutputFormat = formatJSON
+	lintCustomSchemaPaths = []string{s.URL + "/schema.json"}
+	schemaRetries, schemaNoCache = 0, true
+
+	cmd := &cobra.Command{}
+	opts := checkOptionsFromFlags(cmd, cfg)
+	require.Equal(t, 10*time.Second, opts.SchemaLoader.Timeout)
+	client, err := newSchemaHTTPClient(opts.SchemaLoader)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, client.Timeout)
+
+	schemaTimeout = 200 * time.Millisecond
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	start := time.Now()
+	err = checkFunc(cmd, nil)
+	require.Less(t, time.Since(start), 4*time.Second)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitCodeSchema, exitErr.Code)
+
+	var results []CheckResult
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &results), stdout.String())
+	require.Len(t, results, 1)
+	res := results[0]
+	require.NotEmpty(t, res.Errors)
+	require.Equal(t, stageSchema, res.Errors[0].Stage)
+}
+
+func Test_checkFunc_exitZero(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3}`)
+
+	origParser := parser
+	origFiles, origExitZero := checkConfigFiles, checkExitZero
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkExitZero = origFiles, origExitZero
+	}()
+	parser = parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{}, errors.New("boom")
+	})
+	checkConfigFiles = []string{cfg}
+
+	var stdout, stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	var exitErr *ExitError
+	require.ErrorAs(t, checkFunc(cmd, nil), &exitErr)
+	require.Equal(t, ExitCodeParse, exitErr.Code)
+
+	checkExitZero = true
+	stderr.Reset()
+	require.NoError(t, checkFunc(cmd, nil))
+	require.Contains(t, stderr.String(), "boom")
+	require.Contains(t, stderr.String(), "1 failed configuration(s) reported with exit code 0 due to --exit-zero")
+	require.Empty(t, stdout.String())
+}
+
+func TestCheck_autoSchema(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "endpoints": [{"method": "PATCH"}]}`)
+	vendored := filepath.Join(filepath.Dir(cfg), "schema", "krakend.json")
+	require.NoError(t, os.Mkdir(filepath.Dir(vendored), 0o755))
+	require.NoError(t, os.WriteFile(vendored, []byte(`{"type": "object", "required": ["version"]}`), 0o600))
+
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, Output: &out, AutoSchema: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.True(t, res.LintPassed)
+	require.Equal(t, vendored, res.SchemaUsed)
+	require.Contains(t, out.String(), "Using the schema "+vendored)
+
+	// the explicitly selected schema takes precedence over the vendored one
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, LintNoNetwork: true, EmbeddedSchema: testSchema, AutoSchema: true})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "embedded", res.SchemaUsed)
+
+	// without a vendored schema, nothing is linted
+	res, err = Check(CheckOptions{ConfigFile: writeTestConfig(t, `{"version": 3}`), Parser: jsonParser, AutoSchema: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.False(t, res.LintPassed)
+	require.Empty(t, res.SchemaUsed)
+}
+
+func Test_checkFunc_autoSchemaPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "krakend.json")
+	require.NoError(t, os.WriteFile(cfg, []byte(`{"$schema": "./custom.json", "version": 2, "name": "test"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.json"), []byte(`{"type": "object"}`), 0o600))
+
+	origParser := parser
+	origFiles, origFormat, origLint, origSchema, origNoAuto := checkConfigFiles, checkOutputFormat, lintNoNetwork, rawEmbedSchema, checkNoAutoSchema
+	defer func() {
+		parser = origParser
+		checkConfigFiles, checkOutputFormat, lintNoNetwork, rawEmbedSchema, checkNoAutoSchema = origFiles, origFormat, origLint, origSchema, origNoAuto
+	}()
+	parser = jsonParser
+	checkConfigFiles = []string{cfg}
+	checkOutputFormat = formatJSON
+	rawEmbedSchema = testSchema
+	checkNoAutoSchema = false
+
+	for lintFlag, schemaUsed := range map[bool]string{false: filepath.Join(dir, "custom.json"), true: "embedded"} {
+		lintNoNetwork = lintFlag
+		var stdout, stderr bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		err := checkFunc(cmd, nil)
+
+		var results []CheckResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &results), stdout.String())
+		require.Len(t, results, 1)
+		require.Equal(t, schemaUsed, results[0].SchemaUsed)
+		if lintFlag {
+			// the embedded schema requires the version 3
+			require.Error(t, err)
+			require.NotEmpty(t, results[0].Errors)
+			continue
+		}
+		require.NoError(t, err)
+		require.Empty(t, results[0].Errors)
+	}
+}
+
+func TestCheck_maxConfigSize(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "test"}`)
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, MaxConfigSize: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageLoad, res.Errors[0].Stage)
+	require.Equal(t, "the configuration is 30 bytes, over the limit of 10 bytes. Raise it with --max-config-size", res.Errors[0].Message)
+
+	res, err = Check(CheckOptions{ConfigFile: stdinConfig, Stdin: strings.NewReader(`{"version": 3, "name": "test"}`), Parser: jsonParser, MaxConfigSize: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Message, "over the limit of 10 bytes")
+
+	// the rendered source may be bigger than the file
+	p := lastSourceParser{Parser: jsonParser, source: []byte(strings.Repeat(" ", 100))}
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: p, MaxConfigSize: 50})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageParse, res.Errors[0].Stage)
+	require.Contains(t, res.Errors[0].Message, "the resolved configuration is 100 bytes")
+
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: p})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, MaxConfigSize: -1})
+	require.Error(t, err)
+}
+
+func TestCheck_outputConfig(t *testing.T) {
+	cfg := writeTestConfig(t, `{
+  "version": 3,
+  "host": ["http://localhost:8080"],
+  "endpoints": [
+    {"endpoint": "/users/{id}", "backend": [{"url_pattern": "/users/{id}?fields={id}"}]},
+    {"endpoint": "/users/{id}/posts", "backend": [
+      {"url_pattern": "/users/{id}"},
+      {"url_pattern": "/posts/{resp0_id}"}
+    ], "extra_config": {"proxy": {"sequential": true}}}
+  ]
+}`)
+	out := filepath.Join(t.TempDir(), "resolved.json")
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: config.NewParser(), OutputConfig: out})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+
+	var resolved map[string]interface{}
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &resolved))
+	endpoints := resolved["endpoints"].([]interface{})
+	first := endpoints[0].(map[string]interface{})
+	require.Equal(t, "/users/{id}", first["endpoint"])
+	require.Equal(t, "/users/{id}?fields={id}", first["backend"].([]interface{})[0].(map[string]interface{})["url_pattern"])
+	second := endpoints[1].(map[string]interface{})
+	require.Equal(t, "/posts/{resp0_id}", second["backend"].([]interface{})[1].(map[string]interface{})["url_pattern"])
+
+	// the resolved configuration passes the check and resolves to itself
+	again := filepath.Join(t.TempDir(), "again.json")
+	res, err = Check(CheckOptions{ConfigFile: out, Parser: config.NewParser(), OutputConfig: again})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	dataAgain, err := os.ReadFile(again)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(dataAgain))
+
+	// nothing is written when the check fails
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	res, err = Check(CheckOptions{ConfigFile: writeTestConfig(t, `{"version": 2}`), Parser: config.NewParser(), OutputConfig: invalid})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Errors)
+	require.NoFileExists(t, invalid)
+}
@@ -3,6 +3,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -26,10 +27,26 @@ func ExecuteRoot(configParser config.Parser, f Executor, root Root) {
 	root.Execute(configParser, f)
 }
 
+// GetConfigFlag returns the configuration file of the executed command. For the check and fmt
+// commands, accepting several files, it is the first one
 func GetConfigFlag() string {
+	if cfgFile == "" && len(checkConfigFiles) > 0 {
+		return checkConfigFiles[0]
+	}
 	return cfgFile
 }
 
+// GetConfigFiles returns all the configuration files received by the executed command
+func GetConfigFiles() []string {
+	if len(checkConfigFiles) > 0 {
+		return checkConfigFiles
+	}
+	if cfgFile != "" {
+		return []string{cfgFile}
+	}
+	return nil
+}
+
 func GetDebugFlag() bool {
 	return debug > 0
 }
@@ -38,6 +55,12 @@ func GetConfigParser() config.Parser {
 	return parser
 }
 
+// SetParser replaces the parser of the configuration used by the commands, like a custom
+// flexible configuration parser. The parser received by Execute takes precedence, unless it is nil
+func SetParser(configParser config.Parser) {
+	parser = configParser
+}
+
 type FlagBuilder func(*cobra.Command)
 
 func StringFlagBuilder(dst *string, long, short, defaultValue, help string) FlagBuilder {
@@ -46,6 +69,12 @@ func StringFlagBuilder(dst *string, long, short, defaultValue, help string) Flag
 	}
 }
 
+func StringArrayFlagBuilder(dst *[]string, long, short string, defaultValue []string, help string) FlagBuilder {
+	return func(cmd *cobra.Command) {
+		cmd.PersistentFlags().StringArrayVarP(dst, long, short, defaultValue, help)
+	}
+}
+
 func BoolFlagBuilder(dst *bool, long, short string, defaultValue bool, help string) FlagBuilder {
 	return func(cmd *cobra.Command) {
 		cmd.PersistentFlags().BoolVarP(dst, long, short, defaultValue, help)
@@ -70,6 +99,12 @@ func IntFlagBuilder(dst *int, long, short string, defaultValue int, help string)
 	}
 }
 
+func Int64FlagBuilder(dst *int64, long, short string, defaultValue int64, help string) FlagBuilder {
+	return func(cmd *cobra.Command) {
+		cmd.PersistentFlags().Int64VarP(dst, long, short, defaultValue, help)
+	}
+}
+
 func CountFlagBuilder(dst *int, long, short, help string) FlagBuilder {
 	return func(cmd *cobra.Command) {
 		cmd.PersistentFlags().CountVarP(dst, long, short, help)
@@ -96,11 +131,27 @@ func MutuallyExclusive(flags ...string) ConstraintBuilder {
 	}
 }
 
+// Deprecated hides the flag from the help, printing the message when it is used
+func Deprecated(flag, message string) ConstraintBuilder {
+	return func(cmd *cobra.Command) {
+		cmd.PersistentFlags().MarkDeprecated(flag, message)
+	}
+}
+
+// FlagCompletion registers the function completing the values of the flag in the shell
+func FlagCompletion(flag string, f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) ConstraintBuilder {
+	return func(cmd *cobra.Command) {
+		cmd.RegisterFlagCompletionFunc(flag, f)
+	}
+}
+
 type Command struct {
 	Cmd         *cobra.Command
 	Flags       []FlagBuilder
 	once        *sync.Once
 	Constraints []ConstraintBuilder
+	// Children are the nested commands, built along with the command
+	Children []Command
 }
 
 func NewCommand(command *cobra.Command, flags ...FlagBuilder) Command {
@@ -127,6 +178,22 @@ func (c *Command) AddSubCommand(cmd *cobra.Command) {
 	c.Cmd.AddCommand(cmd)
 }
 
+// AddChild nests a command, so its flags and constraints are built with the parent
+func (c *Command) AddChild(child Command) {
+	c.Children = append(c.Children, child)
+}
+
+func (c *Command) build() {
+	c.BuildFlags()
+	for i := range c.Constraints {
+		c.Constraints[i](c.Cmd)
+	}
+	for i := range c.Children {
+		c.Children[i].build()
+		c.Cmd.AddCommand(c.Children[i].Cmd)
+	}
+}
+
 func NewRoot(root Command, subCommands ...Command) Root {
 	r := Root{Command: root, SubCommands: subCommands, once: new(sync.Once)}
 	return r
@@ -146,20 +213,69 @@ func (r *Root) Build() {
 		}
 		for i := range r.SubCommands {
 			s := r.SubCommands[i]
-			s.BuildFlags()
-			for j := range s.Constraints {
-				s.Constraints[j](s.Cmd)
-			}
+			s.build()
 			r.Cmd.AddCommand(s.Cmd)
 		}
 	})
 }
 
 func (r Root) Execute(configParser config.Parser, f Executor) {
-	parser = configParser
+	if configParser != nil {
+		parser = configParser
+	}
 	run = f
-	if err := r.Cmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+	if code, ok := r.execute(); !ok {
+		os.Exit(code)
+	}
+}
+
+// execute runs the command, writing its error to stderr, so stdout only gets the results. It
+// returns the exit code and false when the command fails
+func (r Root) execute() (int, bool) {
+	err := r.Cmd.Execute()
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Err != nil {
+			fmt.Fprintln(r.Cmd.ErrOrStderr(), exitErr.Err)
+		}
+		return exitErr.Code, false
+	}
+	fmt.Fprintln(r.Cmd.ErrOrStderr(), err)
+	return -1, false
+}
+
+// ExitError is returned by the commands requiring the process to terminate with a given code.
+// A nil Err means the failure has already been reported to the user
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// commandExitError returns the failure of a command as an *ExitError, so Execute
+// reports it and exits with its code, 1 unless err is an *ExitError itself
+func commandExitError(cmd *cobra.Command, err error) error {
+	if err == nil {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr
 	}
+	return &ExitError{Code: ExitCodeFailure, Err: err}
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_execute(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		code int
+		ok   bool
+		msg  string
+	}{
+		"ok":                  {ok: true},
+		"exit error":          {err: &ExitError{Code: ExitCodeLint, Err: errors.New("boom")}, code: ExitCodeLint, msg: "boom\n"},
+		"exit error reported": {err: &ExitError{Code: ExitCodeParse}, code: ExitCodeParse},
+		"other error":         {err: errors.New("boom"), code: -1, msg: "boom\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			c := &cobra.Command{
+				Use:           "test",
+				SilenceErrors: true,
+				SilenceUsage:  true,
+				RunE:          func(*cobra.Command, []string) error { return tc.err },
+			}
+			c.SetArgs([]string{})
+			c.SetOut(&stdout)
+			c.SetErr(&stderr)
+
+			code, ok := Root{Command: Command{Cmd: c}}.execute()
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.code, code)
+			require.Empty(t, stdout.String())
+			require.Equal(t, tc.msg, stderr.String())
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args   []string
+		prefix string
+		msg    string
+	}{
+		"deprecated flag": {args: []string{"--indent", "--"}, prefix: "--", msg: "Flag --indent has been deprecated, use --dump-prefix instead\n"},
+		"new flag":        {args: []string{"--dump-prefix", ""}, prefix: ""},
+		"default":         {args: []string{}, prefix: "  "},
+	} {
+		t.Run(name, func(t *testing.T) {
+			prefix := "  "
+			c := NewCommand(
+				&cobra.Command{Use: "test", Run: func(*cobra.Command, []string) {}},
+				StringFlagBuilder(&prefix, "indent", "i", prefix, "Indentation"),
+				StringFlagBuilder(&prefix, "dump-prefix", "", prefix, "Prefix"),
+			)
+			c.AddConstraint(Deprecated("indent", "use --dump-prefix instead"))
+			c.build()
+
+			var stdout, stderr bytes.Buffer
+			c.Cmd.SetArgs(tc.args)
+			c.Cmd.SetOut(&stdout)
+			c.Cmd.SetErr(&stderr)
+			require.NoError(t, c.Cmd.Execute())
+			require.Equal(t, tc.prefix, prefix)
+			require.Equal(t, tc.msg, stdout.String()+stderr.String())
+			require.True(t, c.Cmd.PersistentFlags().Lookup("indent").Hidden)
+		})
+	}
+}
+
+func TestGetConfigFiles(t *testing.T) {
+	a := writeTestConfig(t, `{"version": 3, "name": "a"}`)
+	b := writeTestConfig(t, `{"version": 3, "name": "b"}`)
+
+	origParser, origCfg, origFiles := parser, cfgFile, checkConfigFiles
+	defer func() { parser, cfgFile, checkConfigFiles = origParser, origCfg, origFiles }()
+	parser = jsonParser
+
+	var stdout, stderr bytes.Buffer
+	DefaultRoot.Build()
+	DefaultRoot.Cmd.SetArgs([]string{"check", "-c", a, "-c", b})
+	DefaultRoot.Cmd.SetOut(&stdout)
+	DefaultRoot.Cmd.SetErr(&stderr)
+	defer DefaultRoot.Cmd.SetArgs(nil)
+
+	code, ok := DefaultRoot.execute()
+	require.True(t, ok, stderr.String())
+	require.Zero(t, code)
+	require.Equal(t, a, GetConfigFlag())
+	require.Equal(t, []string{a, b}, GetConfigFiles())
+
+	cfgFile, checkConfigFiles = "krakend.json", nil
+	require.Equal(t, "krakend.json", GetConfigFlag())
+	require.Equal(t, []string{"krakend.json"}, GetConfigFiles())
+}
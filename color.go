@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/krakendio/krakend-cobra/v2/dumper"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var colorModes = []string{colorAuto, colorAlways, colorNever}
+
+// colorize wraps the content with the color, when the colors are enabled
+func colorize(enabled bool, color, content string) string {
+	if !enabled {
+		return content
+	}
+	return color + content + dumper.ColorReset
+}
+
+// UseColors reports if the output should be colored. An explicit --color always or never
+// takes precedence over the NO_COLOR env var, which takes precedence over the IsTTY heuristic
+func UseColors() bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTTY
+}
+
+// useColorsFor reports if the output written to w should be colored, like UseColors does for
+// stderr, checking if w is a terminal instead
+func useColorsFor(w io.Writer) bool {
+	if w == io.Writer(os.Stderr) {
+		return UseColors()
+	}
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+func validateColorMode(cmd *cobra.Command, _ []string) error {
+	if isSupportedFormat(colorMode, colorModes) {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf("unknown color mode %q. Supported modes: %s", colorMode, strings.Join(colorModes, ", "))}
+}
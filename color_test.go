@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/krakendio/krakend-cobra/v2/dumper"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseColors(t *testing.T) {
+	origMode, origTTY := colorMode, IsTTY
+	defer func() { colorMode, IsTTY = origMode, origTTY }()
+
+	for _, tc := range []struct {
+		mode    string
+		tty     bool
+		noColor string
+		want    bool
+	}{
+		{mode: colorAuto, tty: true, want: true},
+		{mode: colorAuto, tty: false, want: false},
+		{mode: colorAuto, tty: true, noColor: "1", want: false},
+		{mode: colorAlways, tty: false, noColor: "1", want: true},
+		{mode: colorNever, tty: true, want: false},
+	} {
+		t.Setenv("NO_COLOR", tc.noColor)
+		colorMode, IsTTY = tc.mode, tc.tty
+		require.Equal(t, tc.want, UseColors(), "%+v", tc)
+	}
+}
+
+func Test_useColorsFor(t *testing.T) {
+	origMode, origTTY := colorMode, IsTTY
+	defer func() { colorMode, IsTTY = origMode, origTTY }()
+	t.Setenv("NO_COLOR", "")
+
+	// the stderr terminal does not make the rest of the writers colored
+	colorMode, IsTTY = colorAuto, true
+	require.False(t, useColorsFor(&bytes.Buffer{}))
+	require.True(t, useColorsFor(os.Stderr))
+
+	colorMode = colorAlways
+	require.True(t, useColorsFor(&bytes.Buffer{}))
+
+	colorMode, IsTTY = colorAuto, true
+	var stdout bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	printCheckSummary(cmd, []CheckResult{{ConfigFile: "a.json"}, {ConfigFile: "b.json", Errors: []CheckError{{Stage: stageLint}}}})
+	require.Equal(t, "\nOK\ta.json\nFAILED\tb.json\n1 OK, 1 FAILED\n", stdout.String())
+}
+
+func Test_validateColorMode(t *testing.T) {
+	origMode := colorMode
+	defer func() { colorMode = origMode }()
+
+	colorMode = colorNever
+	require.NoError(t, validateColorMode(&cobra.Command{}, nil))
+
+	colorMode = "sometimes"
+	err := validateColorMode(&cobra.Command{}, nil)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitCodeUsage, exitErr.Code)
+	require.ErrorContains(t, err, `unknown color mode "sometimes"`)
+}
+
+func Test_colorize(t *testing.T) {
+	require.Equal(t, "boom", colorize(false, dumper.ColorRed, "boom"))
+	require.Equal(t, dumper.ColorRed+"boom"+dumper.ColorReset, colorize(true, dumper.ColorRed, "boom"))
+
+	r := newCheckReporter(nil, true)
+	require.Equal(t, colorize(true, dumper.ColorYellow, "boom"), r.warnMsg("boom"))
+	r.colors = false
+	require.Equal(t, "boom", r.errorMsg("boom"))
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/luraproject/lura/v2/core"
+	"github.com/spf13/cobra"
+)
+
+// configFileExtensions are the extensions completed for the configuration files
+var configFileExtensions = []string{"json", "yaml", "yml", "toml", "tmpl"}
+
+// completeConfigFiles completes the paths of the configuration files
+func completeConfigFiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return configFileExtensions, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeValues completes a flag accepting only the received values
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeSchemaVersions completes the versions of the official online schema, from 2.0 to
+// the version of this binary
+func completeSchemaVersions(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	last := 9
+	if v, err := getVersionMinor(core.KrakendVersion); err == nil {
+		var major int
+		if _, err := fmt.Sscanf(v, "%d.%d", &major, &last); err != nil || major != 2 {
+			last = 9
+		}
+	}
+	versions := make([]string, 0, last+1)
+	for minor := last; minor >= 0; minor-- {
+		versions = append(versions, fmt.Sprintf("2.%d", minor))
+	}
+	return versions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/luraproject/lura/v2/core"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_completeSchemaVersions(t *testing.T) {
+	orig := core.KrakendVersion
+	defer func() { core.KrakendVersion = orig }()
+
+	core.KrakendVersion = "2.6.1"
+	versions, directive := completeSchemaVersions(nil, nil, "")
+	require.Equal(t, []string{"2.6", "2.5", "2.4", "2.3", "2.2", "2.1", "2.0"}, versions)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	core.KrakendVersion = "dev"
+	versions, _ = completeSchemaVersions(nil, nil, "")
+	require.Len(t, versions, 10)
+	require.Equal(t, "2.9", versions[0])
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// checkDefaultsFile is the file of the working directory declaring the defaults of the check
+// flags, with their long names as keys
+const checkDefaultsFile = ".krakend-check.yaml"
+
+// cliDefaultsFile is the path, relative to the home of the user, of the file declaring the
+// defaults of the flags of every command. The top-level keys are the flags of the root command
+// and the sections, named after the subcommands, declare the defaults of their flags
+var cliDefaultsFile = filepath.Join(".config", "krakend", "cli.yaml")
+
+// checkEnvDefaults maps the env vars setting the defaults of the check flags to their names,
+// on top of the ones named after the flags
+var checkEnvDefaults = map[string]string{
+	"KRAKEND_CHECK_GIN_ROUTES": "test-gin-routes",
+}
+
+// mutuallyExclusiveAnnotation is the annotation cobra adds to the mutually exclusive flags
+const mutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+
+// defaultsCommands are the commands taking the defaults of their flags from the env vars and
+// the files. The rest, like run, only take the flags of the command line
+var defaultsCommands = []*cobra.Command{checkCmd, fmtCmd, auditCmd}
+
+// applyCommandDefaults sets the flags not passed in the command line to their defaults. The
+// env vars take precedence over the .krakend-check.yaml file of the check command, and it over
+// the global cli.yaml file
+func applyCommandDefaults(cmd *cobra.Command) error {
+	if !acceptsCommandDefaults(cmd) {
+		return nil
+	}
+	flags := cmd.Flags()
+	envs := commandEnvDefaults(cmd)
+	if cmd == checkCmd {
+		for env, name := range checkEnvDefaults {
+			envs[env] = name
+		}
+	}
+	if err := applyEnvDefaults(flags, envs, os.LookupEnv); err != nil {
+		return err
+	}
+
+	if cmd == checkCmd {
+		values, err := readFlagDefaults(checkDefaultsFile)
+		if err != nil {
+			return err
+		}
+		if err := applyFlagDefaults(flags, values); err != nil {
+			return fmt.Errorf("%s: %w", checkDefaultsFile, err)
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	name := filepath.Join(home, cliDefaultsFile)
+	values, err := readFlagDefaults(name)
+	if err != nil {
+		return err
+	}
+	values, err = commandFileDefaults(values, cmd)
+	if err == nil {
+		err = applyFlagDefaults(flags, values)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func acceptsCommandDefaults(cmd *cobra.Command) bool {
+	for _, c := range defaultsCommands {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// commandEnvDefaults maps the env vars named after the flags of the command to them. The
+// names are prefixed with KRAKEND and the path of the command defining the flag, like
+// KRAKEND_CHECK_SCHEMA_VERSION for --schema-version of check or KRAKEND_COLOR for --color
+func commandEnvDefaults(cmd *cobra.Command) map[string]string {
+	envs := map[string]string{}
+	seen := map[string]bool{"help": true}
+	for c := cmd; c != nil; c = c.Parent() {
+		prefix := commandEnvPrefix(c)
+		c.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+			if seen[f.Name] {
+				return
+			}
+			seen[f.Name] = true
+			envs[prefix+envName(f.Name)] = f.Name
+		})
+	}
+	return envs
+}
+
+func commandEnvPrefix(cmd *cobra.Command) string {
+	var names []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		names = append([]string{envName(c.Name())}, names...)
+	}
+	return strings.Join(append([]string{"KRAKEND"}, names...), "_") + "_"
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// commandFileDefaults selects the defaults of the command from the content of the global
+// file, merging its section with the ones of its parents. The innermost values win
+func commandFileDefaults(values map[string]interface{}, cmd *cobra.Command) (map[string]interface{}, error) {
+	var path []*cobra.Command
+	for c := cmd; c != nil; c = c.Parent() {
+		path = append([]*cobra.Command{c}, path...)
+	}
+
+	merged := map[string]interface{}{}
+	level := values
+	for i, c := range path {
+		var next map[string]interface{}
+		for k, v := range level {
+			sub := subcommandNamed(c, k)
+			if sub == nil {
+				merged[k] = v
+				continue
+			}
+			section, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%q must be a section with the defaults of the %s flags", k, sub.CommandPath())
+			}
+			if i+1 < len(path) && sub == path[i+1] {
+				next = section
+			}
+		}
+		if next == nil {
+			break
+		}
+		level = next
+	}
+	return merged, nil
+}
+
+func subcommandNamed(cmd *cobra.Command, name string) *cobra.Command {
+	for _, c := range cmd.Commands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// readFlagDefaults decodes the YAML file with the defaults of the flags. A missing file
+// declares no defaults
+func readFlagDefaults(name string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return values, nil
+}
+
+// applyEnvDefaults sets the flags not passed in the command line to the value of the env vars
+// mapped to them
+func applyEnvDefaults(flags *pflag.FlagSet, envs map[string]string, lookup func(string) (string, bool)) error {
+	for env, name := range envs {
+		value, ok := lookup(env)
+		if !ok {
+			continue
+		}
+		f := flags.Lookup(name)
+		if f == nil || !acceptsDefault(flags, f) {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q of %s: %w", value, env, err)
+		}
+	}
+	return nil
+}
+
+// applyFlagDefaults sets the flags not passed in the command line to the received values,
+// keyed by the long name of the flags. The lists set the repeatable flags once per element
+func applyFlagDefaults(flags *pflag.FlagSet, values map[string]interface{}) error {
+	for name, value := range values {
+		f := flags.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if !acceptsDefault(flags, f) {
+			continue
+		}
+		elems, ok := value.([]interface{})
+		if !ok {
+			elems = []interface{}{value}
+		}
+		for _, elem := range elems {
+			s := flagDefaultString(elem)
+			if err := flags.Set(name, s); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %w", s, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// acceptsDefault tells if the flag is still unset and none of the flags excluding it is set,
+// so a default never conflicts with the choices of the user
+func acceptsDefault(flags *pflag.FlagSet, f *pflag.Flag) bool {
+	if f.Changed {
+		return false
+	}
+	for _, group := range f.Annotations[mutuallyExclusiveAnnotation] {
+		for _, name := range strings.Fields(group) {
+			if other := flags.Lookup(name); other != nil && other != f && other.Changed {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func flagDefaultString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyFlagDefaults(t *testing.T) {
+	var ginRoutes bool
+	var schemas []string
+	var timeout string
+	flags := pflag.NewFlagSet("check", pflag.ContinueOnError)
+	flags.BoolVar(&ginRoutes, "test-gin-routes", false, "")
+	flags.StringArrayVar(&schemas, "lint-schema", nil, "")
+	flags.StringVar(&timeout, "schema-timeout", "", "")
+	require.NoError(t, flags.Parse([]string{"--schema-timeout", "1s"}))
+
+	name := filepath.Join(t.TempDir(), checkDefaultsFile)
+	require.NoError(t, os.WriteFile(name, []byte("test-gin-routes: true\nlint-schema:\n  - a.json\n  - b.json\nschema-timeout: 5s\n"), 0o644))
+	values, err := readFlagDefaults(name)
+	require.NoError(t, err)
+
+	require.NoError(t, applyFlagDefaults(flags, values))
+	require.True(t, ginRoutes)
+	require.Equal(t, []string{"a.json", "b.json"}, schemas)
+	require.Equal(t, "1s", timeout, "the flags passed explicitly must be kept")
+
+	require.ErrorContains(t, applyFlagDefaults(flags, map[string]interface{}{"unknown": 1}), `unknown flag "unknown"`)
+
+	values, err = readFlagDefaults(filepath.Join(t.TempDir(), checkDefaultsFile))
+	require.NoError(t, err)
+	require.Empty(t, values)
+}
+
+func Test_applyEnvDefaults(t *testing.T) {
+	var ginRoutes bool
+	flags := pflag.NewFlagSet("check", pflag.ContinueOnError)
+	flags.BoolVar(&ginRoutes, "test-gin-routes", false, "")
+
+	lookup := func(v string) func(string) (string, bool) {
+		return func(string) (string, bool) { return v, true }
+	}
+	require.ErrorContains(t, applyEnvDefaults(flags, checkEnvDefaults, lookup("maybe")), "KRAKEND_CHECK_GIN_ROUTES")
+	require.NoError(t, applyEnvDefaults(flags, checkEnvDefaults, lookup("1")))
+	require.True(t, ginRoutes)
+
+	ginRoutes = false
+	flags.Lookup("test-gin-routes").Changed = true
+	require.NoError(t, applyEnvDefaults(flags, checkEnvDefaults, lookup("1")))
+	require.False(t, ginRoutes)
+}
+
+func Test_commandDefaults(t *testing.T) {
+	root := &cobra.Command{Use: "krakend"}
+	root.PersistentFlags().String("color", "", "")
+	schema := &cobra.Command{Use: "schema"}
+	fetch := &cobra.Command{Use: "fetch", Run: func(*cobra.Command, []string) {}}
+	fetch.PersistentFlags().String("version", "", "")
+	fetch.PersistentFlags().String("out", "", "")
+	root.AddCommand(schema)
+	schema.AddCommand(fetch)
+
+	require.Equal(t, map[string]string{
+		"KRAKEND_COLOR":                "color",
+		"KRAKEND_SCHEMA_FETCH_VERSION": "version",
+		"KRAKEND_SCHEMA_FETCH_OUT":     "out",
+	}, commandEnvDefaults(fetch))
+
+	values := map[string]interface{}{
+		"color": "never",
+		"schema": map[string]interface{}{
+			"fetch": map[string]interface{}{"version": "2.6", "color": "always"},
+		},
+	}
+	defaults, err := commandFileDefaults(values, fetch)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"version": "2.6", "color": "always"}, defaults)
+
+	defaults, err = commandFileDefaults(values, root)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"color": "never"}, defaults)
+
+	_, err = commandFileDefaults(map[string]interface{}{"schema": "2.6"}, fetch)
+	require.ErrorContains(t, err, `"schema" must be a section`)
+}
+
+func Test_acceptsDefault(t *testing.T) {
+	cmd := &cobra.Command{Use: "check"}
+	cmd.Flags().Bool("lint", false, "")
+	cmd.Flags().Bool("lint-no-network", false, "")
+	cmd.MarkFlagsMutuallyExclusive("lint", "lint-no-network")
+	require.NoError(t, cmd.Flags().Parse([]string{"--lint-no-network"}))
+
+	require.NoError(t, applyFlagDefaults(cmd.Flags(), map[string]interface{}{"lint": true}))
+	lint, err := cmd.Flags().GetBool("lint")
+	require.NoError(t, err)
+	require.False(t, lint, "a default must not conflict with the flags passed explicitly")
+}
+
+func Test_applyCommandDefaults_commands(t *testing.T) {
+	DefaultRoot.Build()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, filepath.Dir(cliDefaultsFile)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, cliDefaultsFile), []byte("run:\n  port: 9090\n"), 0o600))
+	t.Setenv("KRAKEND_RUN_DEBUG", "3")
+	t.Setenv("KRAKEND_FMT_STDOUT", "true")
+
+	defer func(p, d int, s bool) { port, debug, fmtStdout = p, d, s }(port, debug, fmtStdout)
+	for _, c := range []*cobra.Command{runCmd, fmtCmd} {
+		require.NoError(t, c.ParseFlags(nil))
+		require.NoError(t, applyCommandDefaults(c))
+	}
+	defer func() { fmtCmd.Flags().Lookup("stdout").Changed = false }()
+
+	require.Equal(t, 0, port, "run must not take the defaults of the files")
+	require.Equal(t, 0, debug, "run must not take the defaults of the env vars")
+	require.False(t, runCmd.Flags().Lookup("port").Changed)
+	require.True(t, fmtStdout)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// deprecatedKey is a configuration property still accepted but deprecated in favor of the
+// replacement. The pattern is a JSON pointer where * matches any array index
+type deprecatedKey struct {
+	Pattern     string
+	Since       string
+	Replacement string
+}
+
+var deprecatedKeys = []deprecatedKey{
+	{"/extra_config/telemetry~1opencensus", "2.6", "telemetry/opentelemetry"},
+	{"/extra_config/telemetry~1metrics", "2.6", "telemetry/opentelemetry"},
+	{"/endpoints/*/extra_config/telemetry~1opencensus", "2.6", "telemetry/opentelemetry"},
+	{"/endpoints/*/backend/*/extra_config/telemetry~1opencensus", "2.6", "telemetry/opentelemetry"},
+	{"/endpoints/*/extra_config/qos~1ratelimit~1router/maxRate", "2.0", "max_rate"},
+	{"/endpoints/*/extra_config/qos~1ratelimit~1router/clientMaxRate", "2.0", "client_max_rate"},
+	{"/endpoints/*/backend/*/extra_config/qos~1ratelimit~1proxy/maxRate", "2.0", "max_rate"},
+}
+
+// deprecatedKeysFor returns the keys already deprecated in the target version (MAJOR.MINOR).
+// All of them are returned when the target is empty
+func deprecatedKeysFor(target string) []deprecatedKey {
+	if target == "" {
+		return deprecatedKeys
+	}
+	var keys []deprecatedKey
+	for _, k := range deprecatedKeys {
+		if compareVersions(k.Since, target) <= 0 {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// compareVersions compares two MAJOR.MINOR versions, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	pa, pb := strings.SplitN(a, ".", 2), strings.SplitN(b, ".", 2)
+	for i := 0; i < 2; i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// deprecatedUse is the location of the document using a deprecated key
+type deprecatedUse struct {
+	Location string
+	Key      deprecatedKey
+}
+
+// deprecatedUses returns the uses of the deprecated keys in the document, sorted by location
+func deprecatedUses(doc interface{}, keys []deprecatedKey) []deprecatedUse {
+	var uses []deprecatedUse
+	for _, k := range keys {
+		for _, location := range matchPointerPattern(doc, parseJSONPointer(k.Pattern), nil) {
+			uses = append(uses, deprecatedUse{Location: jsonPointer(location), Key: k})
+		}
+	}
+	sort.SliceStable(uses, func(i, j int) bool {
+		return uses[i].Location < uses[j].Location
+	})
+	return uses
+}
+
+// matchPointerPattern returns the locations of the document matching the pattern
+func matchPointerPattern(v interface{}, pattern, location []string) [][]string {
+	if len(pattern) == 0 {
+		return [][]string{location}
+	}
+	var res [][]string
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if child, ok := t[pattern[0]]; ok {
+			res = matchPointerPattern(child, pattern[1:], append(append([]string{}, location...), pattern[0]))
+		}
+	case []interface{}:
+		for i, child := range t {
+			if token := strconv.Itoa(i); pattern[0] == "*" || pattern[0] == token {
+				res = append(res, matchPointerPattern(child, pattern[1:], append(append([]string{}, location...), token))...)
+			}
+		}
+	}
+	return res
+}
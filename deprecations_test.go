@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deprecatedUses(t *testing.T) {
+	doc := map[string]interface{}{
+		"extra_config": map[string]interface{}{"telemetry/opencensus": map[string]interface{}{}},
+		"endpoints": []interface{}{
+			map[string]interface{}{"extra_config": map[string]interface{}{"qos/ratelimit/router": map[string]interface{}{"max_rate": 10}}},
+			map[string]interface{}{
+				"extra_config": map[string]interface{}{"qos/ratelimit/router": map[string]interface{}{"maxRate": 10}},
+				"backend": []interface{}{
+					map[string]interface{}{},
+					map[string]interface{}{"extra_config": map[string]interface{}{"qos/ratelimit/proxy": map[string]interface{}{"maxRate": 1}}},
+				},
+			},
+		},
+	}
+
+	uses := deprecatedUses(doc, deprecatedKeys)
+	require.Len(t, uses, 3)
+	require.Equal(t, "/endpoints/1/backend/1/extra_config/qos~1ratelimit~1proxy/maxRate", uses[0].Location)
+	require.Equal(t, "/endpoints/1/extra_config/qos~1ratelimit~1router/maxRate", uses[1].Location)
+	require.Equal(t, "max_rate", uses[1].Key.Replacement)
+	require.Equal(t, "/extra_config/telemetry~1opencensus", uses[2].Location)
+	require.Equal(t, "telemetry/opentelemetry", uses[2].Key.Replacement)
+
+	require.Empty(t, deprecatedUses(map[string]interface{}{"version": 3}, deprecatedKeys))
+}
+
+func Test_deprecatedKeysFor(t *testing.T) {
+	require.Equal(t, deprecatedKeys, deprecatedKeysFor(""))
+	for _, k := range deprecatedKeysFor("2.5") {
+		require.Equal(t, "2.0", k.Since)
+	}
+	require.Len(t, deprecatedKeysFor("2.6"), len(deprecatedKeys))
+	require.Empty(t, deprecatedKeysFor("1.4"))
+
+	require.Equal(t, -1, compareVersions("2.6", "2.10"))
+	require.Equal(t, 0, compareVersions("2.6", "2.6"))
+	require.Equal(t, 1, compareVersions("3.0", "2.9"))
+}
+
+func Test_checkDeprecationsDefault(t *testing.T) {
+	// the deprecations are reported only when requested, so the output of the check is unchanged
+	require.False(t, checkOptionsFromFlags(&cobra.Command{}, "krakend.json").CheckDeprecations)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/krakendio/krakend-cobra/v2/dumper"
+	"github.com/spf13/cobra"
+)
+
+// ConfigChange is a difference between two resolved configurations
+type ConfigChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ConfigDiff groups the differences between two resolved configurations
+type ConfigDiff struct {
+	Added   []ConfigChange `json:"added"`
+	Removed []ConfigChange `json:"removed"`
+	Changed []ConfigChange `json:"changed"`
+}
+
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func diffFunc(cmd *cobra.Command, args []string) {
+	d, err := diffFuncErr(cmd, args)
+	if err != nil {
+		cmd.Println(errorMsg(err.Error()))
+		os.Exit(1) // skipcq: RVV-A0003
+	}
+	if !d.Empty() {
+		os.Exit(1) // skipcq: RVV-A0003
+	}
+}
+
+func diffFuncErr(cmd *cobra.Command, _ []string) (ConfigDiff, error) {
+	if cfgFile == "" || diffConfigB == "" {
+		return ConfigDiff{}, fmt.Errorf("please, provide the paths to both configuration files with --config and --config-b or see all the options with --help")
+	}
+	if diffFormat != formatText && diffFormat != formatJSON {
+		return ConfigDiff{}, fmt.Errorf("unknown output format %q. Supported formats: %s, %s", diffFormat, formatText, formatJSON)
+	}
+
+	a, err := parser.Parse(cfgFile)
+	if err != nil {
+		return ConfigDiff{}, fmt.Errorf("parsing %s: %w", cfgFile, err)
+	}
+	a.Normalize()
+
+	b, err := parser.Parse(diffConfigB)
+	if err != nil {
+		return ConfigDiff{}, fmt.Errorf("parsing %s: %w", diffConfigB, err)
+	}
+	b.Normalize()
+
+	d := diffDocuments(resolvedConfig(a), resolvedConfig(b))
+
+	if diffFormat == formatJSON {
+		enc := newJSONEncoder(cmd.OutOrStdout())
+		return d, enc.Encode(d)
+	}
+
+	printConfigDiff(cmd, d, UseColors())
+	return d, nil
+}
+
+func printConfigDiff(cmd *cobra.Command, d ConfigDiff, colored bool) {
+	if d.Empty() {
+		cmd.Println("No differences found")
+		return
+	}
+
+	red, green, yellow, reset := "", "", "", ""
+	if colored {
+		red, green, yellow, reset = dumper.ColorRed, dumper.ColorGreen, dumper.ColorYellow, dumper.ColorReset
+	}
+
+	for _, c := range d.Removed {
+		cmd.Printf("%s- %s: %s%s\n", red, c.Path, diffValue(c.Old), reset)
+	}
+	for _, c := range d.Added {
+		cmd.Printf("%s+ %s: %s%s\n", green, c.Path, diffValue(c.New), reset)
+	}
+	for _, c := range d.Changed {
+		cmd.Printf("%s~ %s: %s -> %s%s\n", yellow, c.Path, diffValue(c.Old), diffValue(c.New), reset)
+	}
+	cmd.Printf("%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+}
+
+func diffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// diffDocuments compares two generic documents leaf by leaf, identifying every leaf by its
+// JSON pointer. The results are sorted by path
+func diffDocuments(a, b interface{}) ConfigDiff {
+	left := map[string]interface{}{}
+	right := map[string]interface{}{}
+	flattenDocument("", a, left)
+	flattenDocument("", b, right)
+
+	d := ConfigDiff{Added: []ConfigChange{}, Removed: []ConfigChange{}, Changed: []ConfigChange{}}
+	for p, lv := range left {
+		rv, ok := right[p]
+		if !ok {
+			d.Removed = append(d.Removed, ConfigChange{Path: p, Old: lv})
+			continue
+		}
+		if !reflect.DeepEqual(lv, rv) {
+			d.Changed = append(d.Changed, ConfigChange{Path: p, Old: lv, New: rv})
+		}
+	}
+	for p, rv := range right {
+		if _, ok := left[p]; !ok {
+			d.Added = append(d.Added, ConfigChange{Path: p, New: rv})
+		}
+	}
+
+	for _, l := range [][]ConfigChange{d.Added, d.Removed, d.Changed} {
+		sort.Slice(l, func(i, j int) bool { return l[i].Path < l[j].Path })
+	}
+	return d
+}
+
+func flattenDocument(prefix string, v interface{}, dst map[string]interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			dst[prefix] = t
+			return
+		}
+		for k, child := range t {
+			flattenDocument(prefix+"/"+jsonPointerEscaper.Replace(k), child, dst)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			dst[prefix] = t
+			return
+		}
+		for i, child := range t {
+			flattenDocument(prefix+"/"+strconv.Itoa(i), child, dst)
+		}
+	default:
+		if prefix == "" {
+			prefix = "/"
+		}
+		dst[prefix] = t
+	}
+}
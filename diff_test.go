@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_diffDocuments(t *testing.T) {
+	a := config.ServiceConfig{
+		Name:    "a",
+		Timeout: time.Second,
+		Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/foo", Method: "GET", Backend: []*config.Backend{{URLPattern: "/foo", Host: []string{"http://a"}}}},
+		},
+	}
+	b := config.ServiceConfig{
+		Name: "a",
+		Port: 8080,
+		Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/foo", Method: "POST", Backend: []*config.Backend{{URLPattern: "/foo", Host: []string{"http://a"}}}},
+		},
+	}
+
+	d := diffDocuments(resolvedConfig(a), resolvedConfig(b))
+	require.Equal(t, []ConfigChange{{Path: "/port", New: 8080}}, d.Added)
+	require.Equal(t, []ConfigChange{{Path: "/timeout", Old: "1s"}}, d.Removed)
+	require.Equal(t, []ConfigChange{{Path: "/endpoints/0/method", Old: "GET", New: "POST"}}, d.Changed)
+
+	require.True(t, diffDocuments(resolvedConfig(a), resolvedConfig(a)).Empty())
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// configFormats are the formats of the configuration files
+var configFormats = []string{formatJSON, formatYAML, formatTOML}
+
+var (
+	tomlKeyValuePattern = regexp.MustCompile(`^[A-Za-z0-9_."'-]+\s*=`)
+	tomlTablePattern    = regexp.MustCompile(`^\[\[?[A-Za-z0-9_.\- ]+\]\]?\s*(#.*)?$`)
+)
+
+// utf8BOM is the byte order mark some editors write at the start of the UTF-8 files
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// documentFormat detects the format of a configuration by its extension or, when the
+// extension is not conclusive, by its content
+func documentFormat(name string, data []byte) string {
+	if format := extensionFormat(name); format != "" {
+		return format
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if looksLikeTOML(data) {
+		return formatTOML
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return formatJSON
+	}
+	return formatYAML
+}
+
+// extensionFormat returns the format of the file by its extension, or an empty string when
+// the extension is unknown
+func extensionFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	}
+	return ""
+}
+
+// looksLikeTOML checks if the first statement of the content is a TOML table header or
+// key/value pair, which are not valid JSON or YAML
+func looksLikeTOML(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return tomlKeyValuePattern.MatchString(line) || tomlTablePattern.MatchString(line)
+	}
+	return false
+}
+
+// decodeDocument converts the content of a configuration into the generic model of
+// encoding/json, so it can be validated against the schema. The returned positions
+// refer to the original content, without the leading byte order mark
+func decodeDocument(name string, data []byte) (interface{}, sourcePositions, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	switch documentFormat(name, data) {
+	case formatYAML:
+		return decodeYAMLDocument(data)
+	case formatTOML:
+		return decodeTOMLDocument(data)
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	return raw, newJSONPositions(data), nil
+}
+
+// tolerantJSONChanges counts the non-strict JSON constructs removed by tolerantJSON
+type tolerantJSONChanges struct {
+	Comments       int
+	TrailingCommas int
+}
+
+func (c tolerantJSONChanges) altered() bool {
+	return c.Comments > 0 || c.TrailingCommas > 0
+}
+
+// tolerantJSON removes the // and /* */ comments and the trailing commas of the objects and
+// arrays from the JSON content, so it can be decoded strictly. The removed bytes are replaced
+// by spaces, keeping the line breaks, so the positions still refer to the original content
+func tolerantJSON(data []byte) ([]byte, tolerantJSONChanges) { // skipcq: GO-R1005
+	res := append([]byte{}, data...)
+	var changes tolerantJSONChanges
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if res[i] != '\n' && res[i] != '\r' {
+				res[i] = ' '
+			}
+		}
+	}
+
+	comma := -1
+	for i := 0; i < len(res); i++ {
+		switch c := res[i]; {
+		case c == '"':
+			comma = -1
+			for i++; i < len(res) && res[i] != '"'; i++ {
+				if res[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(res) && res[i+1] == '/':
+			end := bytes.IndexByte(res[i:], '\n')
+			if end < 0 {
+				end = len(res) - i
+			}
+			blank(i, i+end)
+			changes.Comments++
+			i += end - 1
+		case c == '/' && i+1 < len(res) && res[i+1] == '*':
+			end := bytes.Index(res[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(res) - i
+			} else {
+				end += 4
+			}
+			blank(i, i+end)
+			changes.Comments++
+			i += end - 1
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				res[comma] = ' '
+				changes.TrailingCommas++
+			}
+			comma = -1
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			comma = -1
+		}
+	}
+	return res, changes
+}
+
+func decodeYAMLDocument(data []byte) (interface{}, sourcePositions, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, err
+	}
+
+	positions := yamlPositions{"/": {1, 1}}
+	if root.Kind == 0 {
+		return nil, positions, nil
+	}
+
+	v, err := yamlValue(&root, "", positions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := normalizeJSONValue(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, positions, nil
+}
+
+// decodeTOMLDocument converts a TOML document. The decoder does not expose the position of
+// the values, so the findings are not located
+func decodeTOMLDocument(data []byte) (interface{}, sourcePositions, error) {
+	var v map[string]interface{}
+	if err := toml.Unmarshal(data, &v); err != nil {
+		var de *toml.DecodeError
+		if errors.As(err, &de) {
+			line, col := de.Position()
+			return nil, nil, fmt.Errorf("toml: line %d, column %d: %s", line, col, strings.TrimPrefix(de.Error(), "toml: "))
+		}
+		return nil, nil, err
+	}
+
+	raw, err := normalizeJSONValue(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, nil, nil
+}
+
+// normalizeJSONValue converts the scalars of a generic value to the types produced by encoding/json
+func normalizeJSONValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// yamlValue converts the node into a generic value, recording the position of every
+// member and item
+func yamlValue(n *yaml.Node, pointer string, positions yamlPositions) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		positions["/"] = [2]int{n.Content[0].Line, n.Content[0].Column}
+		return yamlValue(n.Content[0], pointer, positions)
+
+	case yaml.AliasNode:
+		return yamlValue(n.Alias, pointer, positions)
+
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(n.Content))
+		for i, item := range n.Content {
+			child := pointer + "/" + strconv.Itoa(i)
+			positions[child] = [2]int{item.Line, item.Column}
+			v, err := yamlValue(item, child, positions)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		// the merged mappings go first, so the explicit keys override them
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Tag == "!!merge" {
+				if err := mergeYAMLValue(m, n.Content[i+1], pointer, positions); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				continue
+			}
+			if k.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("yaml: line %d: unsupported non scalar key", k.Line)
+			}
+			child := pointer + "/" + jsonPointerEscaper.Replace(k.Value)
+			positions[child] = [2]int{k.Line, k.Column}
+			value, err := yamlValue(v, child, positions)
+			if err != nil {
+				return nil, err
+			}
+			m[k.Value] = value
+		}
+		return m, nil
+
+	default:
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// mergeYAMLValue adds to the mapping the keys of the merged node (a mapping or a list of
+// mappings) not already present
+func mergeYAMLValue(m map[string]interface{}, n *yaml.Node, pointer string, positions yamlPositions) error {
+	sources := []*yaml.Node{n}
+	if n.Kind == yaml.SequenceNode {
+		sources = n.Content
+	}
+	for _, src := range sources {
+		v, err := yamlValue(src, pointer, positions)
+		if err != nil {
+			return err
+		}
+		merged, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("yaml: line %d: map merge requires a mapping", src.Line)
+		}
+		for k, val := range merged {
+			if _, ok := m[k]; !ok {
+				m[k] = val
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_documentFormat(t *testing.T) {
+	require.Equal(t, formatJSON, documentFormat("krakend.json", []byte("version: 3")))
+	require.Equal(t, formatYAML, documentFormat("krakend.yml", []byte(`{"version": 3}`)))
+	require.Equal(t, formatJSON, documentFormat("krakend.tmpl", []byte("\n  {\"version\": 3}")))
+	require.Equal(t, formatYAML, documentFormat("", []byte("version: 3")))
+	require.Equal(t, formatJSON, documentFormat("", []byte("\xef\xbb\xbf{\"version\": 3}")))
+}
+
+func Test_decodeDocument_bom(t *testing.T) {
+	raw, _, err := decodeDocument("krakend.json", []byte("\xef\xbb\xbf{\"version\": 3}"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"version": float64(3)}, raw)
+}
+
+func Test_tolerantJSON(t *testing.T) {
+	data := []byte(`{
+  // the service
+  "name": "a, b // c",
+  "endpoints": [
+    {"endpoint": "/foo", /* inline */ "timeout": "3s",},
+  ],
+}`)
+	res, changes := tolerantJSON(data)
+	require.Equal(t, tolerantJSONChanges{Comments: 2, TrailingCommas: 3}, changes)
+	require.Len(t, res, len(data))
+
+	raw, positions, err := decodeDocument("krakend.json", res)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"name":      "a, b // c",
+		"endpoints": []interface{}{map[string]interface{}{"endpoint": "/foo", "timeout": "3s"}},
+	}, raw)
+	line, _ := positions.Position("/endpoints/0/timeout")
+	require.Equal(t, 5, line)
+
+	res, changes = tolerantJSON([]byte(`{"a": [1, 2]}`))
+	require.False(t, changes.altered())
+	require.Equal(t, `{"a": [1, 2]}`, string(res))
+}
+
+func Test_decodeDocument_yaml(t *testing.T) {
+	data := []byte(`version: 3
+defaults: &defaults
+  method: GET
+endpoints:
+  - endpoint: /foo
+    <<: *defaults
+  - endpoint: /bar
+    method: POST
+    timeout: 3s
+`)
+	raw, positions, err := decodeDocument("krakend.yaml", data)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"version":  float64(3),
+		"defaults": map[string]interface{}{"method": "GET"},
+		"endpoints": []interface{}{
+			map[string]interface{}{"endpoint": "/foo", "method": "GET"},
+			map[string]interface{}{"endpoint": "/bar", "method": "POST", "timeout": "3s"},
+		},
+	}, raw)
+
+	for pointer, want := range map[string][2]int{
+		"/":                    {1, 1},
+		"/version":             {1, 1},
+		"/endpoints/1":         {7, 5},
+		"/endpoints/1/method":  {8, 5},
+		"/endpoints/1/missing": {7, 5},
+	} {
+		line, col := positions.Position(pointer)
+		require.Equal(t, want, [2]int{line, col}, pointer)
+	}
+}
+
+func Test_decodeDocument_yamlError(t *testing.T) {
+	_, _, err := decodeDocument("krakend.yaml", []byte("version: 3\n  endpoints: [\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line")
+}
+
+func Test_looksLikeTOML(t *testing.T) {
+	require.True(t, looksLikeTOML([]byte("# comment\nversion = 3")))
+	require.True(t, looksLikeTOML([]byte("[[endpoints]]\nendpoint = \"/foo\"")))
+	require.False(t, looksLikeTOML([]byte(`["a", "b"]`)))
+	require.False(t, looksLikeTOML([]byte("version: 3")))
+	require.False(t, looksLikeTOML([]byte(`{"version": 3}`)))
+}
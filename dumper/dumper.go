@@ -1,6 +1,8 @@
 package dumper
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"sort"
 
@@ -46,6 +48,9 @@ type Dumper struct {
 	colorWhite      string
 }
 
+// Dump prints the parsed configuration. The returned error joins all the issues found while
+// dumping it (see errors.Join), like the undefined endpoints, agents and backends or the
+// unknown methods of the endpoints, so they can be reported together
 func (c Dumper) Dump(v config.ServiceConfig) error {
 	c.cmd.Printf("%sGlobal settings%s\n", c.colorGreen, c.colorReset)
 	c.cmd.Printf("%sName: %s\n", c.checkDumpPrefix, v.Name)
@@ -135,19 +140,28 @@ func (c Dumper) Dump(v config.ServiceConfig) error {
 		c.dumpExtraConfig(v.ExtraConfig, "")
 	}
 
+	var errs []error
 	c.cmd.Printf("%s%d API endpoint(s):%s\n", c.colorGreen, len(v.Endpoints), c.colorReset)
-	for _, endpoint := range v.Endpoints {
-		c.dumpEndpoint(endpoint)
+	for i, endpoint := range v.Endpoints {
+		if endpoint == nil {
+			errs = append(errs, fmt.Errorf("endpoint %d is not defined", i))
+			continue
+		}
+		errs = append(errs, c.dumpEndpoint(endpoint)...)
 	}
 
 	c.cmd.Printf("%s%d async agent(s):%s\n", c.colorGreen, len(v.AsyncAgents), c.colorReset)
-	for _, agent := range v.AsyncAgents {
-		c.dumpAgent(agent)
+	for i, agent := range v.AsyncAgents {
+		if agent == nil {
+			errs = append(errs, fmt.Errorf("async agent %d is not defined", i))
+			continue
+		}
+		errs = append(errs, c.dumpAgent(agent)...)
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func (c Dumper) dumpAgent(agent *config.AsyncAgent) {
+func (c Dumper) dumpAgent(agent *config.AsyncAgent) []error {
 	c.cmd.Printf("%s- %s%s%s\n", c.checkDumpPrefix, c.colorCyan, agent.Name, c.colorReset)
 
 	if c.verboseLevel > 1 {
@@ -169,12 +183,15 @@ func (c Dumper) dumpAgent(agent *config.AsyncAgent) {
 	}
 
 	c.cmd.Printf("%s%sConnecting to %d backend(s):%s\n", c.checkDumpPrefix, c.colorGreen, len(agent.Backend), c.colorReset)
-	for _, backend := range agent.Backend {
-		c.dumpBackend(backend)
-	}
+	return c.dumpBackends("async agent "+agent.Name, agent.Backend)
 }
 
-func (c Dumper) dumpEndpoint(endpoint *config.EndpointConfig) {
+func (c Dumper) dumpEndpoint(endpoint *config.EndpointConfig) []error {
+	var errs []error
+	if endpoint.Method != "" && !isKnownMethod(endpoint.Method) {
+		errs = append(errs, fmt.Errorf("endpoint %s: unknown method %q", endpoint.Endpoint, endpoint.Method))
+	}
+
 	c.cmd.Printf("%s- %s%s%s %s%s\n", c.checkDumpPrefix, c.methodColor(endpoint.Method), endpoint.Method, c.colorCyan, endpoint.Endpoint, c.colorReset)
 	c.cmd.Printf("%sTimeout: %s\n", c.checkDumpPrefix, endpoint.Timeout.String())
 
@@ -198,9 +215,20 @@ func (c Dumper) dumpEndpoint(endpoint *config.EndpointConfig) {
 	}
 
 	c.cmd.Printf("%s%sConnecting to %d backend(s):%s\n", c.checkDumpPrefix, c.colorGreen, len(endpoint.Backend), c.colorReset)
-	for _, backend := range endpoint.Backend {
+	return append(errs, c.dumpBackends("endpoint "+endpoint.Endpoint, endpoint.Backend)...)
+}
+
+// dumpBackends dumps the backends of the owner, returning the ones not defined as errors
+func (c Dumper) dumpBackends(owner string, backends []*config.Backend) []error {
+	var errs []error
+	for i, backend := range backends {
+		if backend == nil {
+			errs = append(errs, fmt.Errorf("%s: backend %d is not defined", owner, i))
+			continue
+		}
 		c.dumpBackend(backend)
 	}
+	return errs
 }
 
 func (c Dumper) dumpBackend(backend *config.Backend) {
@@ -255,6 +283,15 @@ func (c Dumper) dumpExtraConfig(cfg config.ExtraConfig, prefix string) {
 	}
 }
 
+func isKnownMethod(s string) bool {
+	switch s {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
 func (c Dumper) methodColor(s string) string {
 	switch s {
 	case http.MethodGet:
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// envReferencePattern matches the env function of the flexible configuration templates. The
+// quotes can be escaped, as the templates are usually declared inside JSON strings
+var envReferencePattern = regexp.MustCompile(`\benv\s+\\?"([A-Za-z_][A-Za-z0-9_]*)\\?"`)
+
+// envReference is a use of an environment variable in the configuration
+type envReference struct {
+	Name     string
+	Location string
+	Line     int
+	Column   int
+}
+
+// unsetEnvReferences scans the raw content of the configuration looking for references to
+// environment variables not set in the process. When the content can be decoded, the
+// references are located by their JSON pointer. Otherwise, only the line and column are known
+func unsetEnvReferences(name string, data []byte, lookup func(string) (string, bool)) []envReference {
+	var refs []envReference
+	if doc, positions, err := decodeDocument(name, data); err == nil {
+		walkStrings(doc, "", func(pointer, s string) {
+			for _, m := range envReferencePattern.FindAllStringSubmatch(s, -1) {
+				ref := envReference{Name: m[1], Location: pointer}
+				if positions != nil {
+					ref.Line, ref.Column = positions.Position(pointer)
+				}
+				refs = append(refs, ref)
+			}
+		})
+	} else {
+		for _, m := range envReferencePattern.FindAllSubmatchIndex(data, -1) {
+			line := bytes.Count(data[:m[0]], []byte("\n")) + 1
+			col := m[0] - bytes.LastIndexByte(data[:m[0]], '\n')
+			refs = append(refs, envReference{Name: string(data[m[2]:m[3]]), Line: line, Column: col})
+		}
+	}
+
+	var unset []envReference
+	for _, ref := range refs {
+		if _, ok := lookup(ref.Name); !ok {
+			unset = append(unset, ref)
+		}
+	}
+	sort.SliceStable(unset, func(i, j int) bool {
+		if unset[i].Line != unset[j].Line {
+			return unset[i].Line < unset[j].Line
+		}
+		return unset[i].Location < unset[j].Location
+	})
+	return unset
+}
+
+// walkStrings calls visit with every string value of the document and its JSON pointer
+func walkStrings(v interface{}, pointer string, visit func(pointer, s string)) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			walkStrings(child, pointer+"/"+jsonPointerEscaper.Replace(k), visit)
+		}
+	case []interface{}:
+		for i, child := range t {
+			walkStrings(child, pointer+"/"+strconv.Itoa(i), visit)
+		}
+	case string:
+		if pointer == "" {
+			pointer = "/"
+		}
+		visit(pointer, t)
+	}
+}
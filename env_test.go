@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_unsetEnvReferences(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return "", name == "SET_VAR"
+	}
+
+	data := []byte("{\n  \"name\": \"{{ env \\\"SET_VAR\\\" }}\",\n  \"endpoints\": [{\"endpoint\": \"/{{ env \\\"MISSING\\\" }}\"}]\n}")
+	require.Equal(t, []envReference{
+		{Name: "MISSING", Location: "/endpoints/0/endpoint", Line: 3, Column: 18},
+	}, unsetEnvReferences("krakend.json", data, lookup))
+
+	tmpl := []byte("{\n  \"port\": {{ env \"PORT_VAR\" }},\n  \"name\": \"{{ env \\\"OTHER_VAR\\\" }} {{ env \\\"SET_VAR\\\" }}\"\n}")
+	require.Equal(t, []envReference{
+		{Name: "PORT_VAR", Line: 2, Column: 14},
+		{Name: "OTHER_VAR", Line: 3, Column: 15},
+	}, unsetEnvReferences("krakend.json", tmpl, lookup))
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strconv"
+)
+
+// lintExplanation describes the properties at the locations matching the pattern, a JSON
+// pointer where * matches any array index
+type lintExplanation struct {
+	Pattern     string
+	Explanation string
+	DocURL      string
+}
+
+var lintExplanations = []lintExplanation{
+	{"/version", "The version of the configuration file format. It must be 3 for KrakenD 2", "https://www.krakend.io/docs/configuration/structure/"},
+	{"/port", "The port the service listens on", "https://www.krakend.io/docs/service-settings/"},
+	{"/timeout", "The default timeout of the endpoints, a duration like 3s", "https://www.krakend.io/docs/service-settings/"},
+	{"/cache_ttl", "The default Cache-Control max-age of the responses, a duration like 300s", "https://www.krakend.io/docs/service-settings/"},
+	{"/tls", "The TLS settings of the HTTP server", "https://www.krakend.io/docs/service-settings/tls/"},
+	{"/extra_config", "The settings of the service components, declared under their namespace", "https://www.krakend.io/docs/configuration/structure/"},
+	{"/async_agent", "The consumers of the messaging systems", "https://www.krakend.io/docs/async/"},
+	{"/endpoints", "The list of endpoints exposed by the gateway", "https://www.krakend.io/docs/endpoints/"},
+	{"/endpoints/*", "An endpoint exposed by the gateway. It requires the endpoint path and the backend list", "https://www.krakend.io/docs/endpoints/"},
+	{"/endpoints/*/endpoint", "The path of the endpoint, starting with a slash. The {placeholders} declare its parameters", "https://www.krakend.io/docs/endpoints/"},
+	{"/endpoints/*/method", "The HTTP method of the endpoint, in uppercase", "https://www.krakend.io/docs/endpoints/"},
+	{"/endpoints/*/output_encoding", "How the response is encoded for the client, like json, negotiate or no-op", "https://www.krakend.io/docs/endpoints/content-types/"},
+	{"/endpoints/*/extra_config", "The settings of the endpoint components, declared under their namespace", "https://www.krakend.io/docs/endpoints/"},
+	{"/endpoints/*/backend", "The list of backends the endpoint connects to", "https://www.krakend.io/docs/backends/"},
+	{"/endpoints/*/backend/*", "A backend of the endpoint. It requires the url_pattern", "https://www.krakend.io/docs/backends/"},
+	{"/endpoints/*/backend/*/host", "The list of hosts of the backend, including the scheme, like http://localhost:8080", "https://www.krakend.io/docs/backends/"},
+	{"/endpoints/*/backend/*/url_pattern", "The path of the backend, starting with a slash. It can use the {placeholders} of the endpoint", "https://www.krakend.io/docs/backends/"},
+	{"/endpoints/*/backend/*/method", "The HTTP method of the backend request, in uppercase", "https://www.krakend.io/docs/backends/"},
+	{"/endpoints/*/backend/*/encoding", "How the response of the backend is decoded, like json, safejson, xml, rss, string or no-op", "https://www.krakend.io/docs/backends/supported-encodings/"},
+	{"/endpoints/*/backend/*/extra_config", "The settings of the backend components, declared under their namespace", "https://www.krakend.io/docs/backends/"},
+}
+
+// explainFindings describes the findings with the explanation of their closest location
+func explainFindings(findings []LintFinding) {
+	for i := range findings {
+		if e, ok := lintExplanationFor(findings[i].Location); ok {
+			findings[i].Explanation, findings[i].DocURL = e.Explanation, e.DocURL
+		}
+	}
+}
+
+// lintExplanationFor returns the explanation of the location or, when it has none, of its
+// closest ancestor
+func lintExplanationFor(location string) (lintExplanation, bool) {
+	for tokens := parseJSONPointer(location); len(tokens) > 0; tokens = tokens[:len(tokens)-1] {
+		for _, e := range lintExplanations {
+			if matchesPointerPattern(parseJSONPointer(e.Pattern), tokens) {
+				return e, true
+			}
+		}
+	}
+	return lintExplanation{}, false
+}
+
+func matchesPointerPattern(pattern, tokens []string) bool {
+	if len(pattern) != len(tokens) {
+		return false
+	}
+	for i, p := range pattern {
+		if p == "*" {
+			if _, err := strconv.Atoi(tokens[i]); err != nil {
+				return false
+			}
+			continue
+		}
+		if p != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
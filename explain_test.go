@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_explainFindings(t *testing.T) {
+	findings := []LintFinding{
+		{Location: "/endpoints/0/backend/1/encoding"},
+		{Location: "/endpoints/3/backend/0/extra_config/qos~1ratelimit~1proxy/max_rate"},
+		{Location: "/endpoints/2"},
+		{Location: "/endpoints/first"},
+		{Location: "/unknown"},
+		{Location: "/"},
+	}
+	explainFindings(findings)
+
+	require.Equal(t, "https://www.krakend.io/docs/backends/supported-encodings/", findings[0].DocURL)
+	require.Contains(t, findings[1].Explanation, "backend components")
+	require.Contains(t, findings[2].Explanation, "An endpoint")
+	require.Equal(t, "The list of endpoints exposed by the gateway", findings[3].Explanation)
+	require.Empty(t, findings[4].Explanation)
+	require.Empty(t, findings[5].DocURL)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const fmtIndent = "  "
+
+func fmtFunc(cmd *cobra.Command, args []string) {
+	if err := fmtFuncErr(cmd, args); err != nil {
+		cmd.Println(errorMsg(err.Error()))
+		os.Exit(1) // skipcq: RVV-A0003
+	}
+}
+
+func fmtFuncErr(cmd *cobra.Command, _ []string) error {
+	if len(checkConfigFiles) == 0 {
+		return fmt.Errorf("please, provide the path to the configuration file with --config or see all the options with --help")
+	}
+
+	files, err := expandConfigFiles(checkConfigFiles)
+	if err != nil {
+		return err
+	}
+
+	unformatted := 0
+	for _, file := range files {
+		changed, err := fmtConfigFile(cmd, file)
+		if err != nil {
+			return err
+		}
+		if changed && fmtCheck {
+			unformatted++
+		}
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("%d file(s) not formatted", unformatted)
+	}
+	return nil
+}
+
+// fmtConfigFile canonicalizes a single configuration file. It returns true if the content
+// of the file was not already formatted
+func fmtConfigFile(cmd *cobra.Command, file string) (bool, error) {
+	src, err := openConfigSource(cmd.InOrStdin(), file, "", 0)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	if _, err := parser.Parse(src.Path); err != nil {
+		return false, fmt.Errorf("parsing %s: %w", src.Name, src.Error(err))
+	}
+
+	data, err := src.ReadContent()
+	if err != nil {
+		return false, fmt.Errorf("reading %s: %w", src.Name, err)
+	}
+
+	formatted, err := canonicalJSON(data)
+	if err != nil {
+		return false, fmt.Errorf("formatting %s: %w", src.Name, err)
+	}
+
+	changed := !bytes.Equal(data, formatted)
+
+	switch {
+	case fmtCheck:
+		if changed {
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), src.Name)
+		}
+	case fmtStdout || file == stdinConfig:
+		_, err = cmd.OutOrStdout().Write(formatted)
+	case changed:
+		err = writeFilePreservingMode(src.Path, formatted)
+	}
+	return changed, err
+}
+
+// canonicalJSON returns the canonical representation of a JSON document: keys sorted, two spaces
+// of indentation and a trailing new line. Numbers are kept as they were written
+func canonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected content after the JSON document")
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", fmtIndent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFilePreservingMode(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_canonicalJSON(t *testing.T) {
+	b, err := canonicalJSON([]byte(`{"version":3,"name":"<a&b>","extra_config":{"z":1.50,"a":[1e3]}}`))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "extra_config": {
+    "a": [
+      1e3
+    ],
+    "z": 1.50
+  },
+  "name": "<a&b>",
+  "version": 3
+}
+`, string(b))
+
+	_, err = canonicalJSON([]byte(`{"version": 3} {}`))
+	require.ErrorContains(t, err, "unexpected content after the JSON document")
+
+	_, err = canonicalJSON([]byte(`{"version": `))
+	require.Error(t, err)
+}
+
+func Test_fmtFuncErr(t *testing.T) {
+	const formatted = "{\n  \"name\": \"test\",\n  \"version\": 3\n}\n"
+
+	for name, tc := range map[string]struct {
+		check   bool
+		stdout  bool
+		content string
+		out     string
+		file    string
+		err     string
+	}{
+		"rewrite":         {content: `{"version": 3, "name": "test"}`, file: formatted},
+		"already":         {content: formatted, file: formatted},
+		"stdout":          {stdout: true, content: `{"version": 3, "name": "test"}`, out: formatted, file: `{"version": 3, "name": "test"}`},
+		"check changed":   {check: true, content: `{"version": 3, "name": "test"}`, out: "krakend.json\n", file: `{"version": 3, "name": "test"}`, err: "1 file(s) not formatted"},
+		"check formatted": {check: true, content: formatted, file: formatted},
+		"invalid":         {content: `{"version": 3, "name": "test"`, file: `{"version": 3, "name": "test"`, err: "parsing"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := writeTestConfig(t, tc.content)
+
+			origParser, origFiles, origCheck, origStdout := parser, checkConfigFiles, fmtCheck, fmtStdout
+			defer func() { parser, checkConfigFiles, fmtCheck, fmtStdout = origParser, origFiles, origCheck, origStdout }()
+			parser, checkConfigFiles, fmtCheck, fmtStdout = jsonParser, []string{cfg}, tc.check, tc.stdout
+
+			var stdout bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+
+			err := fmtFuncErr(cmd, nil)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, strings.ReplaceAll(tc.out, "krakend.json", cfg), stdout.String())
+
+			b, err := os.ReadFile(cfg)
+			require.NoError(t, err)
+			require.Equal(t, tc.file, string(b))
+		})
+	}
+}
+
+func Test_fmtFuncErr_stdin(t *testing.T) {
+	origParser, origFiles, origCheck, origStdout := parser, checkConfigFiles, fmtCheck, fmtStdout
+	defer func() { parser, checkConfigFiles, fmtCheck, fmtStdout = origParser, origFiles, origCheck, origStdout }()
+	parser, checkConfigFiles, fmtCheck, fmtStdout = jsonParser, []string{stdinConfig}, false, false
+
+	var stdout bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetIn(strings.NewReader(`{"version": 3}`))
+	require.NoError(t, fmtFuncErr(cmd, nil))
+	require.Equal(t, "{\n  \"version\": 3\n}\n", stdout.String())
+
+	checkConfigFiles = nil
+	require.ErrorContains(t, fmtFuncErr(cmd, nil), "provide the path to the configuration file")
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"text/template"
+	"text/template/parse"
+)
+
+const (
+	includeConfig   = "config"
+	includeSettings = "settings"
+	includeTemplate = "template"
+	includePartial  = "partial"
+)
+
+// IncludeNode is a file read by the flexible configuration while rendering the configuration,
+// with the files it includes
+type IncludeNode struct {
+	// Kind is config, settings, template or partial
+	Kind string `json:"kind"`
+	// Path is the absolute path of the file
+	Path     string         `json:"path"`
+	Missing  bool           `json:"missing,omitempty"`
+	Includes []*IncludeNode `json:"includes,omitempty"`
+}
+
+func newIncludeNode(kind, path string) *IncludeNode {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	_, err := os.Stat(path)
+	return &IncludeNode{Kind: kind, Path: path, Missing: err != nil}
+}
+
+// traceIncludes builds the tree of the files the flexible configuration reads to render the
+// configuration: the settings, the shared templates it executes and the partials they include.
+// The parser does not expose the files it opens, so the templates are parsed the same way
+// checkTemplates does and walked. Only the partials and templates named by a literal are traced
+func traceIncludes(name string, content []byte, dirs TemplateDirs) (*IncludeNode, error) {
+	root := newIncludeNode(includeConfig, name)
+	if dirs.Settings != "" {
+		settings, err := filepath.Glob(filepath.Join(dirs.Settings, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range settings {
+			root.Includes = append(root.Includes, newIncludeNode(includeSettings, f))
+		}
+	}
+
+	tmpl := template.New(filepath.Base(name)).Funcs(template.FuncMap{
+		"marshal": func(interface{}) string { return "" },
+		"include": func(string) string { return "" },
+		"env":     os.Getenv,
+	})
+	files := map[string]string{}
+	if dirs.Templates != "" {
+		shared, err := filepath.Glob(filepath.Join(dirs.Templates, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range shared {
+			b, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := parseTemplate(tmpl.New(filepath.Base(f)), string(b)); err != nil {
+				return nil, err
+			}
+			files[filepath.Base(f)] = f
+		}
+	}
+	if err := parseTemplate(tmpl, string(content)); err != nil {
+		return nil, err
+	}
+
+	t := &includeTracer{tmpl: tmpl, files: files, partials: dirs.Partials, visiting: map[string]bool{}}
+	t.walk(root, tmpl.Name())
+	return root, nil
+}
+
+type includeTracer struct {
+	tmpl     *template.Template
+	files    map[string]string
+	partials string
+	// visiting are the templates being walked, so the recursive ones are walked once
+	visiting map[string]bool
+}
+
+// walk adds the includes of the template to the node
+func (t *includeTracer) walk(node *IncludeNode, name string) {
+	tmpl := t.tmpl.Lookup(name)
+	if tmpl == nil || tmpl.Tree == nil || t.visiting[name] {
+		return
+	}
+	t.visiting[name] = true
+	defer delete(t.visiting, name)
+	t.walkNode(node, tmpl.Tree.Root)
+}
+
+func (t *includeTracer) walkNode(node *IncludeNode, n parse.Node) { // skipcq: GO-R1005
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			t.walkNode(node, child)
+		}
+	case *parse.ActionNode:
+		t.walkNode(node, n.Pipe)
+	case *parse.IfNode:
+		t.walkBranch(node, &n.BranchNode)
+	case *parse.RangeNode:
+		t.walkBranch(node, &n.BranchNode)
+	case *parse.WithNode:
+		t.walkBranch(node, &n.BranchNode)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			t.walkNode(node, cmd)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 1 {
+			if id, ok := n.Args[0].(*parse.IdentifierNode); ok && id.Ident == "include" {
+				if partial, ok := n.Args[1].(*parse.StringNode); ok {
+					node.Includes = append(node.Includes, newIncludeNode(includePartial, filepath.Join(t.partials, partial.Text)))
+				}
+			}
+		}
+		for _, arg := range n.Args {
+			t.walkNode(node, arg)
+		}
+	case *parse.TemplateNode:
+		t.walkNode(node, n.Pipe)
+		file, ok := t.files[n.Name]
+		if !ok {
+			// a template defined inside an already traced file
+			t.walk(node, n.Name)
+			return
+		}
+		child := newIncludeNode(includeTemplate, file)
+		node.Includes = append(node.Includes, child)
+		t.walk(child, n.Name)
+	}
+}
+
+func (t *includeTracer) walkBranch(node *IncludeNode, n *parse.BranchNode) {
+	t.walkNode(node, n.Pipe)
+	t.walkNode(node, n.List)
+	t.walkNode(node, n.ElseList)
+}
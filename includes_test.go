@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_traceIncludes(t *testing.T) {
+	dir := t.TempDir()
+	dirs := TemplateDirs{
+		Settings:  filepath.Join(dir, "settings"),
+		Partials:  filepath.Join(dir, "partials"),
+		Templates: filepath.Join(dir, "templates"),
+	}
+	for _, d := range []string{dirs.Settings, dirs.Partials, dirs.Templates} {
+		require.NoError(t, os.Mkdir(d, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Settings, "service.json"), []byte(`{"port": 8080}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Partials, "timeout.json"), []byte(`"timeout": "3s",`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Templates, "endpoint.tmpl"), []byte(`{ {{ include "timeout.json" }} "endpoint": "/{{ .name }}"}`), 0o600))
+
+	cfg := filepath.Join(dir, "krakend.tmpl")
+	content := []byte(`{
+  "version": 3,
+  {{ if .service.port }}{{ include "missing.json" }}{{ end }}
+  "endpoints": [{{ range .endpoints }}{{ template "endpoint.tmpl" . }}{{ end }}]
+}`)
+	require.NoError(t, os.WriteFile(cfg, content, 0o600))
+
+	root, err := traceIncludes(cfg, content, dirs)
+	require.NoError(t, err)
+	require.Equal(t, &IncludeNode{
+		Kind: includeConfig,
+		Path: cfg,
+		Includes: []*IncludeNode{
+			{Kind: includeSettings, Path: filepath.Join(dirs.Settings, "service.json")},
+			{Kind: includePartial, Path: filepath.Join(dirs.Partials, "missing.json"), Missing: true},
+			{
+				Kind:     includeTemplate,
+				Path:     filepath.Join(dirs.Templates, "endpoint.tmpl"),
+				Includes: []*IncludeNode{{Kind: includePartial, Path: filepath.Join(dirs.Partials, "timeout.json")}},
+			},
+		},
+	}, root)
+
+	_, err = traceIncludes(cfg, []byte(`{{ .port }`), dirs)
+	require.Error(t, err)
+}
+
+func TestCheck_traceIncludes(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	var out bytes.Buffer
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: jsonParser, Output: &out, Quiet: true, TraceIncludes: true})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, &IncludeNode{Kind: includeConfig, Path: cfg}, res.Includes)
+	require.Equal(t, "Includes:\n\t"+cfg+" (config)\n", out.String())
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonIndentAuto pretty-prints the JSON outputs in interactive terminals and compacts them
+// otherwise, so they are ready for the log ingestion
+const jsonIndentAuto = -1
+
+// jsonIndentation returns the indentation of the JSON outputs selected by --json-indent
+func jsonIndentation() string {
+	spaces := jsonIndent
+	if spaces == jsonIndentAuto {
+		spaces = 0
+		if IsTTY {
+			spaces = len(fmtIndent)
+		}
+	}
+	return strings.Repeat(" ", spaces)
+}
+
+// newJSONEncoder returns an encoder of the JSON outputs, indented as selected by --json-indent
+func newJSONEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", jsonIndentation())
+	return enc
+}
+
+func validateJSONIndent(cmd *cobra.Command, _ []string) error {
+	if jsonIndent >= jsonIndentAuto {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: ExitCodeUsage, Err: fmt.Errorf("invalid JSON indentation %d. Use the number of spaces, or 0 for a compact output", jsonIndent)}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newJSONEncoder(t *testing.T) {
+	origIndent, origTTY := jsonIndent, IsTTY
+	defer func() { jsonIndent, IsTTY = origIndent, origTTY }()
+
+	for _, tc := range []struct {
+		indent int
+		tty    bool
+		want   string
+	}{
+		{indent: jsonIndentAuto, tty: true, want: "{\n  \"a\": 1\n}\n"},
+		{indent: jsonIndentAuto, tty: false, want: "{\"a\":1}\n"},
+		{indent: 0, tty: true, want: "{\"a\":1}\n"},
+		{indent: 4, tty: false, want: "{\n    \"a\": 1\n}\n"},
+	} {
+		jsonIndent, IsTTY = tc.indent, tc.tty
+		out := new(bytes.Buffer)
+		require.NoError(t, newJSONEncoder(out).Encode(map[string]int{"a": 1}))
+		require.Equal(t, tc.want, out.String(), "%+v", tc)
+	}
+}
+
+func Test_validateJSONIndent(t *testing.T) {
+	origIndent := jsonIndent
+	defer func() { jsonIndent = origIndent }()
+
+	for _, indent := range []int{jsonIndentAuto, 0, 4} {
+		jsonIndent = indent
+		require.NoError(t, validateJSONIndent(&cobra.Command{}, nil), indent)
+	}
+
+	jsonIndent = jsonIndentAuto - 1
+	err := validateJSONIndent(&cobra.Command{}, nil)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitCodeUsage, exitErr.Code)
+}
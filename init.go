@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func initFunc(cmd *cobra.Command, args []string) error {
+	return commandExitError(cmd, initFuncErr(cmd, args))
+}
+
+func initFuncErr(cmd *cobra.Command, _ []string) error {
+	if initListTemplates {
+		for _, t := range initTemplates {
+			cmd.Printf("%s\t%s\n", t.Name, t.Description)
+		}
+		return nil
+	}
+	tmpl, ok := findInitTemplate(initTemplateName)
+	if !ok {
+		return fmt.Errorf("unknown template %q. Available templates: %s", initTemplateName, strings.Join(initTemplateNames(), ", "))
+	}
+	if initOut == "" {
+		return errors.New("please, provide the path of the configuration file to create with --out")
+	}
+	if _, err := os.Stat(initOut); err == nil && !initForce {
+		return fmt.Errorf("%s already exists. Use --force to overwrite it", initOut)
+	}
+
+	schemaURL := ""
+	if initLinkSchema {
+		var err error
+		if schemaURL, err = onlineSchemaURL(onlineSchemaBaseURL(), schemaVersion); err != nil {
+			return err
+		}
+	}
+	data, err := newInitConfig(tmpl, schemaURL)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(initOut, data); err != nil {
+		return fmt.Errorf("writing %s: %w", initOut, err)
+	}
+	cmd.Println(okMsg(fmt.Sprintf("Configuration saved to %s from the %s template", initOut, tmpl.Name)))
+
+	res, err := Check(CheckOptions{
+		ConfigFile:        initOut,
+		Output:            cmd.OutOrStderr(),
+		Colors:            UseColors(),
+		LintNoNetwork:     rawEmbedSchema != "",
+		EmbeddedSchema:    rawEmbedSchema,
+		CheckDeprecations: true,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("the generated configuration %s is not valid", initOut)
+	}
+	return nil
+}
+
+// newInitConfig renders the configuration of the template, linking the schema when the URL
+// is not empty
+func newInitConfig(tmpl initTemplate, schemaURL string) ([]byte, error) {
+	cfg := tmpl.Config()
+	if schemaURL != "" {
+		cfg["$schema"] = schemaURL
+	}
+	data, err := json.MarshalIndent(cfg, "", fmtIndent)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
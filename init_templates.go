@@ -0,0 +1,143 @@
+package cmd
+
+// initTemplate is a starter configuration generated by the init command
+type initTemplate struct {
+	Name        string
+	Description string
+	// Config returns the content of the configuration, without the $schema property
+	Config func() map[string]interface{}
+}
+
+const initDefaultTemplate = "minimal"
+
+// initTemplates are the starter configurations, in the order they are listed. All of them
+// must pass the check
+var initTemplates = []initTemplate{
+	{
+		Name:        initDefaultTemplate,
+		Description: "A single endpoint proxying a public backend",
+		Config: func() map[string]interface{} {
+			return initService(initEndpoint("/hello", initBackend("/posts/1")))
+		},
+	},
+	{
+		Name:        "rest-proxy",
+		Description: "A REST API proxying the CRUD operations of a resource, with CORS and timeouts",
+		Config: func() map[string]interface{} {
+			cfg := initService(
+				initEndpoint("/users", initBackend("/users")),
+				initEndpoint("/users/{id}", initBackend("/users/{id}")),
+				initWriteEndpoint("/users", "POST", "/users"),
+				initWriteEndpoint("/users/{id}", "PUT", "/users/{id}"),
+				initWriteEndpoint("/users/{id}", "DELETE", "/users/{id}"),
+			)
+			cfg["timeout"] = "3s"
+			cfg["cache_ttl"] = "300s"
+			cfg["extra_config"] = map[string]interface{}{
+				"security/cors": map[string]interface{}{
+					"allow_origins":  []string{"*"},
+					"allow_methods":  []string{"GET", "POST", "PUT", "DELETE"},
+					"allow_headers":  []string{"Origin", "Authorization", "Content-Type"},
+					"expose_headers": []string{"Content-Length"},
+					"max_age":        "12h",
+				},
+			}
+			return cfg
+		},
+	},
+	{
+		Name:        "jwt-gateway",
+		Description: "Endpoints protected by JWT tokens validated against a JWK set, forwarding the claims to the backends",
+		Config: func() map[string]interface{} {
+			public := initEndpoint("/public/posts", initBackend("/posts"))
+			private := initEndpoint("/me/posts", initBackend("/posts"))
+			private["input_headers"] = []string{"X-User"}
+			private["extra_config"] = map[string]interface{}{
+				"auth/validator": map[string]interface{}{
+					"alg":              "RS256",
+					"jwk_url":          "https://auth.example.com/.well-known/jwks.json",
+					"audience":         []string{"https://api.example.com"},
+					"issuer":           "https://auth.example.com/",
+					"cache":            true,
+					"propagate_claims": [][]string{{"sub", "X-User"}},
+				},
+			}
+			return initService(public, private)
+		},
+	},
+	{
+		Name:        "aggregation",
+		Description: "An endpoint merging the responses of several backends, with groups and filters",
+		Config: func() map[string]interface{} {
+			user := initBackend("/users/{id}")
+			user["allow"] = []string{"id", "name", "email"}
+			posts := initBackend("/posts?userId={id}")
+			posts["group"] = "posts"
+			posts["is_collection"] = true
+			todos := initBackend("/todos?userId={id}")
+			todos["group"] = "todos"
+			todos["is_collection"] = true
+
+			e := initEndpoint("/users/{id}/dashboard", user, posts, todos)
+			e["timeout"] = "2s"
+			cfg := initService(e)
+			cfg["timeout"] = "3s"
+			return cfg
+		},
+	},
+}
+
+// findInitTemplate returns the template with the name, if any
+func findInitTemplate(name string) (initTemplate, bool) {
+	for _, t := range initTemplates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return initTemplate{}, false
+}
+
+func initTemplateNames() []string {
+	names := make([]string, len(initTemplates))
+	for i, t := range initTemplates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func initService(endpoints ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"version":   3,
+		"name":      "My KrakenD API Gateway",
+		"port":      8080,
+		"endpoints": endpoints,
+	}
+}
+
+func initEndpoint(path string, backends ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"endpoint": path,
+		"method":   "GET",
+		"backend":  backends,
+	}
+}
+
+// initWriteEndpoint proxies a write operation as is, so the backend receives the body and
+// the content type of the request
+func initWriteEndpoint(path, method, urlPattern string) map[string]interface{} {
+	b := initBackend(urlPattern)
+	b["method"] = method
+	b["encoding"] = "no-op"
+	e := initEndpoint(path, b)
+	e["method"] = method
+	e["output_encoding"] = "no-op"
+	e["input_headers"] = []string{"Content-Type"}
+	return e
+}
+
+func initBackend(urlPattern string) map[string]interface{} {
+	return map[string]interface{}{
+		"host":        []string{"https://jsonplaceholder.typicode.com"},
+		"url_pattern": urlPattern,
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_initFuncErr(t *testing.T) {
+	origParser, origOut, origForce, origLink, origVersion := parser, initOut, initForce, initLinkSchema, schemaVersion
+	defer func() {
+		parser, initOut, initForce, initLinkSchema, schemaVersion = origParser, origOut, origForce, origLink, origVersion
+	}()
+	parser = config.NewParser()
+	initOut = filepath.Join(t.TempDir(), "krakend.json")
+	initForce, initLinkSchema = false, false
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	require.NoError(t, initFuncErr(cmd, nil))
+	require.Contains(t, out.String(), "Syntax OK!")
+
+	cfg, err := config.NewParser().Parse(initOut)
+	require.NoError(t, err)
+	require.Len(t, cfg.Endpoints, 1)
+
+	require.ErrorContains(t, initFuncErr(cmd, nil), "already exists")
+
+	initForce, initLinkSchema, schemaVersion = true, true, "2.6"
+	require.NoError(t, initFuncErr(cmd, nil))
+	data, err := os.ReadFile(initOut)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Equal(t, "https://www.krakend.io/schema/v2.6/krakend.json", doc["$schema"])
+
+	schemaVersion = "../x"
+	var exitErr *ExitError
+	require.ErrorAs(t, initFunc(cmd, nil), &exitErr)
+	require.Equal(t, ExitCodeUsage, exitErr.Code)
+}
+
+func Test_initTemplates(t *testing.T) {
+	for _, tmpl := range initTemplates {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			data, err := newInitConfig(tmpl, "")
+			require.NoError(t, err)
+			cfg := writeTestConfig(t, string(data))
+
+			res, err := Check(CheckOptions{ConfigFile: cfg, Parser: config.NewParser(), CheckDeprecations: true, CheckNamespaces: true})
+			require.NoError(t, err)
+			require.Empty(t, res.Errors)
+			require.Empty(t, res.Warnings)
+		})
+	}
+
+	_, ok := findInitTemplate("unknown")
+	require.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/spf13/cobra"
+)
+
+// serialParser serializes the use of a parser shared by concurrent checks, as the parsers keep
+// state between calls. The source assembled for every check is kept by its own serialParser
+type serialParser struct {
+	mu   *sync.Mutex
+	next config.Parser
+}
+
+func (p *serialParser) Parse(path string) (config.ServiceConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.next.Parse(path)
+}
+
+type serialSourcer struct {
+	serialParser
+	source    []byte
+	sourceErr error
+}
+
+func (p *serialSourcer) Parse(path string) (config.ServiceConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cfg, err := p.next.Parse(path)
+	p.source, p.sourceErr = p.next.(LastSourcer).LastSource()
+	return cfg, err
+}
+
+func (p *serialSourcer) LastSource() ([]byte, error) {
+	return p.source, p.sourceErr
+}
+
+func newSerialParser(mu *sync.Mutex, next config.Parser) config.Parser {
+	if _, ok := next.(LastSourcer); ok {
+		return &serialSourcer{serialParser: serialParser{mu: mu, next: next}}
+	}
+	return &serialParser{mu: mu, next: next}
+}
+
+// fileCheck is a check running in the background. Its messages and dumps are buffered, so
+// they are written in the order of the files
+type fileCheck struct {
+	res    CheckResult
+	err    error
+	output bytes.Buffer
+	dump   bytes.Buffer
+	source bytes.Buffer
+	done   chan struct{}
+	// skipped tells the check never started, as the context was done before
+	skipped bool
+}
+
+// checkFiles checks the files with up to jobs checks running at the same time, calling report
+// with every result in the order of the files. It stops at the first invalid options, and
+// skips the files not checked yet once the context is done. The checks without a context of
+// their own are aborted by it
+func checkFiles(ctx context.Context, cmd *cobra.Command, files []string, jobs int, newOptions func(file string) CheckOptions, report func(CheckResult)) error {
+	if jobs <= 1 || len(files) <= 1 {
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return nil
+			}
+			opts := newOptions(file)
+			if opts.Context == nil {
+				opts.Context = ctx
+			}
+			res, err := Check(opts)
+			if err != nil {
+				return err
+			}
+			report(res)
+		}
+		return nil
+	}
+
+	checks := make([]*fileCheck, len(files))
+	for i := range checks {
+		checks[i] = &fileCheck{done: make(chan struct{})}
+	}
+	sem := make(chan struct{}, jobs)
+	parserMu := &sync.Mutex{}
+	go func() {
+		for i, file := range files {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				for _, c := range checks[i:] {
+					c.skipped = true
+					close(c.done)
+				}
+				return
+			}
+			go func(c *fileCheck, file string) {
+				defer func() {
+					<-sem
+					close(c.done)
+				}()
+				opts := newOptions(file)
+				if opts.Context == nil {
+					opts.Context = ctx
+				}
+				if opts.Parser == nil {
+					opts.Parser = parser
+				}
+				opts.Parser = newSerialParser(parserMu, opts.Parser)
+				if opts.Output != nil {
+					opts.Output = &c.output
+				}
+				if opts.DumpOutput != nil {
+					opts.DumpOutput = &c.dump
+				}
+				if opts.SourceOutput != nil {
+					opts.SourceOutput = &c.source
+				}
+				c.res, c.err = Check(opts)
+			}(checks[i], file)
+		}
+	}()
+
+	for _, c := range checks {
+		<-c.done
+		if c.skipped {
+			return nil
+		}
+		if c.err != nil {
+			return c.err
+		}
+		flushBuffer(cmd.ErrOrStderr(), &c.output)
+		flushBuffer(cmd.OutOrStdout(), &c.dump)
+		flushBuffer(cmd.OutOrStdout(), &c.source)
+		report(c.res)
+	}
+	return nil
+}
+
+func flushBuffer(w io.Writer, b *bytes.Buffer) {
+	if b.Len() > 0 {
+		_, _ = b.WriteTo(w)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 8; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("krakend-%d.json", i))
+		require.NoError(t, os.WriteFile(name, []byte(`{"version": 3}`), 0o600))
+		files = append(files, name)
+	}
+
+	var mu sync.Mutex
+	parsing := 0
+	p := parserFunc(func(path string) (config.ServiceConfig, error) {
+		mu.Lock()
+		parsing++
+		concurrent := parsing > 1
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			parsing--
+			mu.Unlock()
+		}()
+		if concurrent {
+			return config.ServiceConfig{}, fmt.Errorf("concurrent parse of %s", path)
+		}
+		return config.ServiceConfig{Version: 3}, nil
+	})
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetErr(&out)
+	schemaCache := &SchemaCache{}
+	newOptions := func(file string) CheckOptions {
+		return CheckOptions{ConfigFile: file, Parser: p, Output: &bytes.Buffer{}, LintNoNetwork: true, EmbeddedSchema: testSchema, SchemaCache: schemaCache}
+	}
+
+	var reported []string
+	require.NoError(t, checkFiles(context.Background(), cmd, files, 4, newOptions, func(res CheckResult) {
+		require.Empty(t, res.Errors)
+		require.True(t, res.LintPassed)
+		reported = append(reported, res.ConfigFile)
+	}))
+	require.Equal(t, files, reported)
+
+	var order []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "Parsing configuration file: ") {
+			order = append(order, strings.TrimPrefix(line, "Parsing configuration file: "))
+		}
+	}
+	require.Equal(t, files, order)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, jobs := range []int{1, 4} {
+		reported = nil
+		require.NoError(t, checkFiles(ctx, cmd, files, jobs, newOptions, func(res CheckResult) {
+			require.Equal(t, stageAborted, res.Errors[0].Stage)
+			reported = append(reported, res.ConfigFile)
+		}))
+		require.Less(t, len(reported), len(files))
+	}
+}
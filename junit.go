@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// newJUnitReport represents every checked file as a test case, failing with all its errors.
+// The warnings are added as the output of the test case
+func newJUnitReport(results []CheckResult) junitTestSuites {
+	suite := junitTestSuite{Name: "krakend check", TestCases: []junitTestCase{}}
+	for _, res := range results {
+		tc := junitTestCase{Name: res.ConfigFile, ClassName: "krakend.check"}
+		if len(res.Timings) > 0 {
+			var seconds float64
+			for _, t := range res.Timings {
+				seconds += t.Duration.Seconds()
+			}
+			tc.Time = strconv.FormatFloat(seconds, 'f', 3, 64)
+		}
+		if len(res.Errors) > 0 {
+			tc.Failure = &junitFailure{
+				Message: res.Errors[0].Message,
+				Type:    res.Errors[0].Stage,
+				Body:    junitErrorLines(res.Errors),
+			}
+			suite.Failures++
+		}
+		if len(res.Warnings) > 0 {
+			tc.SystemOut = junitErrorLines(res.Warnings)
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	return junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+func junitErrorLines(errs []CheckError) string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		line := "[" + e.Stage + "] "
+		if e.Location != "" {
+			line += e.Location + ": "
+		}
+		lines[i] = line + e.Message
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeJUnit(w io.Writer, results []CheckResult) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(newJUnitReport(results)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
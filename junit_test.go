@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeJUnit(t *testing.T) {
+	results := []CheckResult{
+		{ConfigFile: "ok.json", Errors: []CheckError{}},
+		{
+			ConfigFile: "bad.json",
+			Errors: []CheckError{
+				{Stage: stageLint, Message: "value must be 3", Location: "/version"},
+				{Stage: stageRoutes, Message: "duplicated route"},
+			},
+			Warnings: []CheckError{{Stage: stageLint, Message: "property 'cache_ttl' is deprecated", Location: "/cache_ttl"}},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeJUnit(&out, results))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="krakend check" tests="2" failures="1">
+  <testsuite name="krakend check" tests="2" failures="1">
+    <testcase name="ok.json" classname="krakend.check"></testcase>
+    <testcase name="bad.json" classname="krakend.check">
+      <failure message="value must be 3" type="lint">[lint] /version: value must be 3&#xA;[routes] duplicated route</failure>
+      <system-out>[lint] /cache_ttl: property &#39;cache_ttl&#39; is deprecated</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, out.String())
+}
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var lintPrinter = message.NewPrinter(language.English)
+
+// LintFinding is a single violation of the schema detected while linting a configuration
+type LintFinding struct {
+	Location string
+	Keyword  string
+	Message  string
+	// Severity is severityError or severityWarning. The warnings do not fail the check
+	Severity string
+	// Line and Column locate the finding in the linted source. They are zero when unknown
+	Line   int
+	Column int
+	// Explanation and DocURL describe the property at the location, when explained
+	Explanation string
+	DocURL      string
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// ValidateConfig lints the configuration (JSON, YAML or TOML) against the JSON schema, like
+// the check command does without any network access. It returns the errors and the warnings
+// found, located in the configuration. The error reports an invalid schema or configuration
+func ValidateConfig(configBytes, schemaBytes []byte) ([]LintFinding, error) {
+	sch, err := compileEmbeddedSchema(schemaBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, positions, err := decodeDocument("", configBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the configuration: %w", err)
+	}
+	findings, warnings := validateDocument([]*jsonschema.Schema{sch}, raw, false)
+	findings = append(findings, warnings...)
+	sortFindings(findings)
+	locateFindings(positions, findings)
+	return findings, nil
+}
+
+// compileEmbeddedSchema compiles a schema received as content, like the one embedded in the
+// binary, instead of loaded from a location
+func compileEmbeddedSchema(schemaBytes []byte) (*jsonschema.Schema, error) {
+	rawSchema, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("parsing the schema: %w", err)
+	}
+	compiler := newSchemaCompiler()
+	if err := compiler.AddResource("schema.json", rawSchema); err != nil {
+		return nil, fmt.Errorf("compiling the schema: %w", err)
+	}
+	sch, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("compiling the schema: %w", err)
+	}
+	return sch, nil
+}
+
+// validateDocument validates the document against every schema, returning the errors and the
+// warnings found. Only the first schema is strict, as the rest describe a subset of the document
+func validateDocument(schemas []*jsonschema.Schema, doc interface{}, strict bool) ([]LintFinding, []LintFinding) {
+	var findings, warnings []LintFinding
+	for _, sch := range schemas {
+		if err := sch.Validate(doc); err != nil {
+			findings = append(findings, lintFindings(err)...)
+		}
+		warnings = append(warnings, deprecationFindings(sch, doc)...)
+	}
+	if strict {
+		findings = append(findings, strictFindings(schemas[0], doc)...)
+	}
+	sortFindings(findings)
+	sortFindings(warnings)
+	return findings, warnings
+}
+
+// locateFindings fills the line and column of the findings, if the positions are known
+func locateFindings(positions sourcePositions, findings []LintFinding) {
+	if positions == nil {
+		return
+	}
+	for i := range findings {
+		findings[i].Line, findings[i].Column = positions.Position(findings[i].Location)
+	}
+}
+
+// lintFindings walks the tree of causes of a validation error and returns all its leaves,
+// so every violation is reported in a single run
+func lintFindings(err error) []LintFinding {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []LintFinding{{Location: "/", Message: err.Error(), Severity: severityError}}
+	}
+	var findings []LintFinding
+	collectLintFindings(verr, &findings)
+	sortFindings(findings)
+	return findings
+}
+
+func collectLintFindings(verr *jsonschema.ValidationError, findings *[]LintFinding) {
+	if len(verr.Causes) == 0 {
+		*findings = append(*findings, LintFinding{
+			Location: jsonPointer(verr.InstanceLocation),
+			Keyword:  strings.Join(verr.ErrorKind.KeywordPath(), "/"),
+			Message:  verr.ErrorKind.LocalizedString(lintPrinter),
+			Severity: severityError,
+		})
+		return
+	}
+	for _, cause := range verr.Causes {
+		collectLintFindings(cause, findings)
+	}
+}
+
+// strictFindings reports the properties of the document not described by the schema. Only the
+// objects whose schema declares properties are inspected, so free-form maps are not reported
+func strictFindings(sch *jsonschema.Schema, doc interface{}) []LintFinding {
+	var findings []LintFinding
+	walkProperties([]*jsonschema.Schema{sch}, doc, nil, func(location []string, _ []*jsonschema.Schema, closed, described bool) bool {
+		if closed && !described {
+			findings = append(findings, LintFinding{
+				Location: jsonPointer(location),
+				Keyword:  "strict",
+				Message:  "property '" + location[len(location)-1] + "' is not described by the schema",
+				Severity: severityError,
+			})
+			return false
+		}
+		return true
+	})
+	sortFindings(findings)
+	return findings
+}
+
+// deprecationFindings reports, as warnings, the properties of the document described by a
+// deprecated schema
+func deprecationFindings(sch *jsonschema.Schema, doc interface{}) []LintFinding {
+	var findings []LintFinding
+	walkProperties([]*jsonschema.Schema{sch}, doc, nil, func(location []string, schemas []*jsonschema.Schema, _, _ bool) bool {
+		for _, s := range expandSchemas(schemas) {
+			if s.Deprecated {
+				findings = append(findings, LintFinding{
+					Location: jsonPointer(location),
+					Keyword:  "deprecated",
+					Message:  "property '" + location[len(location)-1] + "' is deprecated",
+					Severity: severityWarning,
+				})
+				break
+			}
+		}
+		return true
+	})
+	sortFindings(findings)
+	return findings
+}
+
+// walkProperties calls visit with every property of the objects of the document and the schemas
+// describing it. closed tells if the schemas of the parent object declare its properties. The
+// value of the property is walked only when visit returns true
+func walkProperties(schemas []*jsonschema.Schema, v interface{}, location []string, visit func(location []string, schemas []*jsonschema.Schema, closed, described bool) bool) {
+	schemas = expandSchemas(schemas)
+	if len(schemas) == 0 {
+		return
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		closed := false
+		for _, s := range schemas {
+			if len(s.Properties) > 0 || len(s.PatternProperties) > 0 {
+				closed = true
+				break
+			}
+		}
+		for k, child := range t {
+			children, described := propertySchemas(schemas, k)
+			childLocation := append(append([]string{}, location...), k)
+			if visit(childLocation, children, closed, described) {
+				walkProperties(children, child, childLocation, visit)
+			}
+		}
+
+	case []interface{}:
+		for i, child := range t {
+			walkProperties(itemSchemas(schemas, i), child, append(append([]string{}, location...), strconv.Itoa(i)), visit)
+		}
+	}
+}
+
+// fragmentOf returns the value of the document at the JSON pointer and the schemas describing it
+func fragmentOf(doc interface{}, schemas []*jsonschema.Schema, pointer string) (interface{}, []*jsonschema.Schema, error) {
+	tokens := parseJSONPointer(pointer)
+	v := doc
+	for i, token := range tokens {
+		found := false
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if v, found = t[token]; found {
+				schemas, _ = propertySchemas(expandSchemas(schemas), token)
+			}
+		case []interface{}:
+			if idx, err := strconv.Atoi(token); err == nil && idx >= 0 && idx < len(t) {
+				v, found = t[idx], true
+				schemas = itemSchemas(expandSchemas(schemas), idx)
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%s does not exist in the configuration", jsonPointer(tokens[:i+1]))
+		}
+	}
+	if len(schemas) == 0 {
+		return nil, nil, fmt.Errorf("the schema does not describe %s", jsonPointer(tokens))
+	}
+	return v, schemas, nil
+}
+
+// parseJSONPointer returns the unescaped tokens of the pointer. Both "" and "/" are the root
+func parseJSONPointer(pointer string) []string {
+	pointer = strings.TrimSuffix(pointer, "/")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = jsonPointerUnescaper.Replace(t)
+	}
+	return tokens
+}
+
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// prefixFindings relocates the findings of a fragment to the document containing it
+func prefixFindings(pointer string, findings []LintFinding) {
+	prefix := jsonPointer(parseJSONPointer(pointer))
+	if prefix == "/" {
+		return
+	}
+	for i := range findings {
+		if findings[i].Location == "/" {
+			findings[i].Location = prefix
+			continue
+		}
+		findings[i].Location = prefix + findings[i].Location
+	}
+}
+
+func sortFindings(findings []LintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Location < findings[j].Location
+	})
+}
+
+// expandSchemas returns the received schemas and all the schemas they reference or compose
+func expandSchemas(schemas []*jsonschema.Schema) []*jsonschema.Schema {
+	var res []*jsonschema.Schema
+	visited := map[*jsonschema.Schema]struct{}{}
+	var visit func(*jsonschema.Schema)
+	visit = func(s *jsonschema.Schema) {
+		if s == nil {
+			return
+		}
+		if _, ok := visited[s]; ok {
+			return
+		}
+		visited[s] = struct{}{}
+		res = append(res, s)
+
+		visit(s.Ref)
+		visit(s.RecursiveRef)
+		if s.DynamicRef != nil {
+			visit(s.DynamicRef.Ref)
+		}
+		for _, l := range [][]*jsonschema.Schema{s.AllOf, s.AnyOf, s.OneOf} {
+			for _, c := range l {
+				visit(c)
+			}
+		}
+		visit(s.If)
+		visit(s.Then)
+		visit(s.Else)
+		for _, c := range s.DependentSchemas {
+			visit(c)
+		}
+	}
+	for _, s := range schemas {
+		visit(s)
+	}
+	return res
+}
+
+// propertySchemas returns the schemas describing the property and false if none does
+func propertySchemas(schemas []*jsonschema.Schema, name string) ([]*jsonschema.Schema, bool) {
+	var res []*jsonschema.Schema
+	described := false
+	for _, s := range schemas {
+		if c, ok := s.Properties[name]; ok {
+			res = append(res, c)
+			described = true
+		}
+		for re, c := range s.PatternProperties {
+			if re.MatchString(name) {
+				res = append(res, c)
+				described = true
+			}
+		}
+		switch ap := s.AdditionalProperties.(type) {
+		case *jsonschema.Schema:
+			res = append(res, ap)
+			described = true
+		case bool:
+			// additionalProperties: false is already enforced by the validation
+			described = described || !ap
+		}
+	}
+	return res, described
+}
+
+func itemSchemas(schemas []*jsonschema.Schema, i int) []*jsonschema.Schema {
+	var res []*jsonschema.Schema
+	for _, s := range schemas {
+		switch items := s.Items.(type) {
+		case *jsonschema.Schema:
+			res = append(res, items)
+		case []*jsonschema.Schema:
+			if i < len(items) {
+				res = append(res, items[i])
+			} else if ai, ok := s.AdditionalItems.(*jsonschema.Schema); ok {
+				res = append(res, ai)
+			}
+		}
+		if i < len(s.PrefixItems) {
+			res = append(res, s.PrefixItems[i])
+		} else if s.Items2020 != nil {
+			res = append(res, s.Items2020)
+		}
+	}
+	return res
+}
+
+// lintSuppressions are the accepted lint findings, ignored by the check. They are declared
+// in a file with one entry per line: JSON pointers (ignoring the findings at that location and
+// below) or schema keywords. Empty lines and lines starting with # are skipped
+type lintSuppressions struct {
+	pointers []string
+	keywords map[string]struct{}
+}
+
+func readLintSuppressions(path string) (*lintSuppressions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseLintSuppressions(f)
+}
+
+func parseLintSuppressions(r io.Reader) (*lintSuppressions, error) {
+	s := &lintSuppressions{keywords: map[string]struct{}{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "/"):
+			s.pointers = append(s.pointers, strings.TrimSuffix(line, "/"))
+		default:
+			s.keywords[line] = struct{}{}
+		}
+	}
+	return s, scanner.Err()
+}
+
+func (s *lintSuppressions) ignores(f LintFinding) bool {
+	if _, ok := s.keywords[f.Keyword]; ok {
+		return true
+	}
+	if i := strings.LastIndexByte(f.Keyword, '/'); i >= 0 {
+		if _, ok := s.keywords[f.Keyword[i+1:]]; ok {
+			return true
+		}
+	}
+	for _, p := range s.pointers {
+		if p == "" || f.Location == p || strings.HasPrefix(f.Location, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// filter splits the findings into the kept and the ignored ones
+func (s *lintSuppressions) filter(findings []LintFinding) ([]LintFinding, []LintFinding) {
+	if s == nil {
+		return findings, nil
+	}
+	var kept, ignored []LintFinding
+	for _, f := range findings {
+		if s.ignores(f) {
+			ignored = append(ignored, f)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, ignored
+}
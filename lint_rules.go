@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/cobra"
+)
+
+// maxRuleDepth bounds the walk of the recursive schemas
+const maxRuleDepth = 16
+
+// SchemaRule summarizes the constraints the schema declares for a location of the
+// configuration. The location is a JSON pointer where * matches any array item, {*} any
+// property and {regexp} the properties matching the pattern
+type SchemaRule struct {
+	Location string   `json:"location"`
+	Types    []string `json:"types,omitempty"`
+	Required []string `json:"required,omitempty"`
+	// Enum lists the accepted values, including the single one of a const
+	Enum       []interface{} `json:"enum,omitempty"`
+	Deprecated bool          `json:"deprecated,omitempty"`
+}
+
+func lintRulesFunc(cmd *cobra.Command, args []string) {
+	if err := lintRulesFuncErr(cmd, args); err != nil {
+		cmd.Println(errorMsg(err.Error()))
+		os.Exit(1) // skipcq: RVV-A0003
+	}
+}
+
+func lintRulesFuncErr(cmd *cobra.Command, _ []string) error {
+	if lintRulesFormat != formatText && lintRulesFormat != formatJSON {
+		return fmt.Errorf("unknown output format %q. Supported formats: %s, %s", lintRulesFormat, formatText, formatJSON)
+	}
+
+	opts := checkOptionsFromFlags(cmd, "")
+	if !opts.shouldLint() {
+		// the schema of the binary, as the check would use
+		opts.LintNoNetwork = rawEmbedSchema != ""
+		opts.Lint = !opts.LintNoNetwork
+	}
+	if err := opts.SchemaLoader.validate(); err != nil {
+		return err
+	}
+
+	r := newCheckReporter(cmd.OutOrStderr(), UseColors())
+	schemas := compileLintSchemas(r, opts)
+	if schemas == nil {
+		return errors.New("the rules can not be listed without a valid schema")
+	}
+
+	rules := schemaRules(schemas)
+	if lintRulesFormat == formatJSON {
+		return newJSONEncoder(cmd.OutOrStdout()).Encode(rules)
+	}
+	for _, rule := range rules {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", rule.Location, rule.String())
+	}
+	return nil
+}
+
+func (r SchemaRule) String() string {
+	var parts []string
+	if len(r.Types) > 0 {
+		parts = append(parts, "type "+strings.Join(r.Types, " or "))
+	}
+	if len(r.Required) > 0 {
+		parts = append(parts, "requires "+strings.Join(r.Required, ", "))
+	}
+	if len(r.Enum) > 0 {
+		values := make([]string, len(r.Enum))
+		for i, v := range r.Enum {
+			b, _ := json.Marshal(v)
+			values[i] = string(b)
+		}
+		parts = append(parts, "one of "+strings.Join(values, ", "))
+	}
+	if r.Deprecated {
+		parts = append(parts, "deprecated")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// schemaRules walks the schemas from the root of the configuration, summarizing the locations
+// declaring any type, required property or accepted values, sorted by location. The required
+// properties and the constraints come from the schemas applying to every value, while the
+// described properties include the ones of the alternatives (anyOf, oneOf and if)
+func schemaRules(schemas []*jsonschema.Schema) []SchemaRule {
+	var rules []SchemaRule
+	var walk func(location []string, schemas []*jsonschema.Schema, stack map[*jsonschema.Schema]bool)
+	walk = func(location []string, schemas []*jsonschema.Schema, stack map[*jsonschema.Schema]bool) {
+		if len(location) > maxRuleDepth {
+			return
+		}
+		var fresh []*jsonschema.Schema
+		for _, s := range schemas {
+			if !stack[s] {
+				fresh = append(fresh, s)
+			}
+		}
+		if len(fresh) == 0 {
+			return
+		}
+		for _, s := range fresh {
+			stack[s] = true
+			defer delete(stack, s)
+		}
+
+		if rule, ok := schemaRuleOf(jsonPointer(location), appliedSchemas(fresh)); ok {
+			rules = append(rules, rule)
+		}
+
+		children := map[string][]*jsonschema.Schema{}
+		for _, s := range expandSchemas(fresh) {
+			for name, c := range s.Properties {
+				children[name] = append(children[name], c)
+			}
+			for re, c := range s.PatternProperties {
+				children["{"+re.String()+"}"] = append(children["{"+re.String()+"}"], c)
+			}
+			if c, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+				children["{*}"] = append(children["{*}"], c)
+			}
+			for _, c := range itemSchemas([]*jsonschema.Schema{s}, 0) {
+				children["*"] = append(children["*"], c)
+			}
+		}
+		for token, c := range children {
+			walk(append(append([]string{}, location...), token), c, stack)
+		}
+	}
+	walk(nil, schemas, map[*jsonschema.Schema]bool{})
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Location < rules[j].Location })
+	return rules
+}
+
+// appliedSchemas returns the schemas and the ones they reference or require with allOf, as
+// all of them apply to the value
+func appliedSchemas(schemas []*jsonschema.Schema) []*jsonschema.Schema {
+	var res []*jsonschema.Schema
+	visited := map[*jsonschema.Schema]bool{}
+	var visit func(*jsonschema.Schema)
+	visit = func(s *jsonschema.Schema) {
+		if s == nil || visited[s] {
+			return
+		}
+		visited[s] = true
+		res = append(res, s)
+		visit(s.Ref)
+		visit(s.RecursiveRef)
+		if s.DynamicRef != nil {
+			visit(s.DynamicRef.Ref)
+		}
+		for _, c := range s.AllOf {
+			visit(c)
+		}
+	}
+	for _, s := range schemas {
+		visit(s)
+	}
+	return res
+}
+
+func schemaRuleOf(location string, schemas []*jsonschema.Schema) (SchemaRule, bool) {
+	rule := SchemaRule{Location: location}
+	types := map[string]bool{}
+	required := map[string]bool{}
+	for _, s := range schemas {
+		if s.Types != nil {
+			for _, t := range s.Types.ToStrings() {
+				types[t] = true
+			}
+		}
+		for _, name := range s.Required {
+			required[name] = true
+		}
+		if s.Enum != nil && rule.Enum == nil {
+			rule.Enum = s.Enum.Values
+		}
+		if s.Const != nil && rule.Enum == nil {
+			rule.Enum = []interface{}{*s.Const}
+		}
+		rule.Deprecated = rule.Deprecated || s.Deprecated
+	}
+	rule.Types = sortedKeys(types)
+	rule.Required = sortedKeys(required)
+	return rule, len(rule.Types) > 0 || len(rule.Required) > 0 || len(rule.Enum) > 0 || rule.Deprecated
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_schemaRules(t *testing.T) {
+	rules := schemaRules([]*jsonschema.Schema{compileTestSchema(t)})
+	require.Equal(t, []SchemaRule{
+		{Location: "/", Types: []string{"object"}, Required: []string{"version"}},
+		{Location: "/cache_ttl", Types: []string{"string"}, Deprecated: true},
+		{Location: "/endpoints", Types: []string{"array"}},
+		{Location: "/endpoints/*", Types: []string{"object"}},
+		{Location: "/endpoints/*/method", Enum: []interface{}{"GET", "POST"}},
+		{Location: "/name", Types: []string{"string"}},
+		{Location: "/version", Enum: []interface{}{json.Number("3")}},
+	}, rules)
+	require.Equal(t, "type object; requires version", rules[0].String())
+	require.Equal(t, `one of "GET", "POST"`, rules[4].String())
+}
+
+func Test_schemaRules_recursive(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(`{
+		"$defs": {"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}}}},
+		"$ref": "#/$defs/node"
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, c.AddResource("tree.json", doc))
+	sch, err := c.Compile("tree.json")
+	require.NoError(t, err)
+
+	rules := schemaRules([]*jsonschema.Schema{sch})
+	require.Equal(t, []string{"/", "/children"}, []string{rules[0].Location, rules[1].Location})
+}
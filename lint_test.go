@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["version"],
+	"properties": {
+		"version": {"const": 3},
+		"name": {"type": "string"},
+		"cache_ttl": {"type": "string", "deprecated": true},
+		"endpoints": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"method": {"enum": ["GET", "POST"]}
+				}
+			}
+		}
+	}
+}`
+
+func compileTestSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(testSchema))
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("schema.json", doc))
+	sch, err := compiler.Compile("schema.json")
+	require.NoError(t, err)
+	return sch
+}
+
+func Test_lintFindings(t *testing.T) {
+	sch := compileTestSchema(t)
+
+	raw, err := jsonschema.UnmarshalJSON(strings.NewReader(`{"name": 42, "endpoints": [{"method": "GETX"}]}`))
+	require.NoError(t, err)
+
+	err = sch.Validate(raw)
+	require.Error(t, err)
+
+	findings := lintFindings(err)
+	require.ElementsMatch(t, []LintFinding{
+		{Location: "/", Keyword: "required", Message: "missing property 'version'", Severity: severityError},
+		{Location: "/name", Keyword: "type", Message: "got number, want string", Severity: severityError},
+		{Location: "/endpoints/0/method", Keyword: "enum", Message: "value must be one of 'GET', 'POST'", Severity: severityError},
+	}, findings)
+}
+
+func Test_strictFindings(t *testing.T) {
+	sch := compileTestSchema(t)
+
+	raw, err := jsonschema.UnmarshalJSON(strings.NewReader(`{"version": 3, "nmae": "x", "endpoints": [{"method": "GET", "metod": "GET"}]}`))
+	require.NoError(t, err)
+	require.NoError(t, sch.Validate(raw))
+
+	require.Equal(t, []LintFinding{
+		{Location: "/endpoints/0/metod", Keyword: "strict", Message: "property 'metod' is not described by the schema", Severity: severityError},
+		{Location: "/nmae", Keyword: "strict", Message: "property 'nmae' is not described by the schema", Severity: severityError},
+	}, strictFindings(sch, raw))
+}
+
+func Test_locateFindings(t *testing.T) {
+	data := []byte("{\n  \"version\": 2,\n  \"endpoints\": [\n    {\"method\": \"GETX\"},\n    {\"a/b\": 1}\n  ]\n}\n")
+	findings := []LintFinding{
+		{Location: "/"},
+		{Location: "/version"},
+		{Location: "/endpoints/0/method"},
+		{Location: "/endpoints/1/a~1b"},
+		{Location: "/endpoints/1/missing"},
+	}
+	locateFindings(newJSONPositions(data), findings)
+
+	want := [][2]int{{1, 1}, {2, 3}, {4, 6}, {5, 6}, {5, 5}}
+	for i, f := range findings {
+		require.Equal(t, want[i], [2]int{f.Line, f.Column}, f.Location)
+	}
+}
+
+func Test_deprecationFindings(t *testing.T) {
+	sch := compileTestSchema(t)
+
+	raw, err := jsonschema.UnmarshalJSON(strings.NewReader(`{"version": 3, "cache_ttl": "3s"}`))
+	require.NoError(t, err)
+	require.NoError(t, sch.Validate(raw))
+
+	require.Equal(t, []LintFinding{
+		{Location: "/cache_ttl", Keyword: "deprecated", Message: "property 'cache_ttl' is deprecated", Severity: severityWarning},
+	}, deprecationFindings(sch, raw))
+}
+
+func Test_lintSuppressions(t *testing.T) {
+	s, err := parseLintSuppressions(strings.NewReader("# accepted findings\n\n/endpoints/0/\nenum\n"))
+	require.NoError(t, err)
+
+	kept, ignored := s.filter([]LintFinding{
+		{Location: "/endpoints/0/method", Keyword: "enum"},
+		{Location: "/endpoints/0", Keyword: "required"},
+		{Location: "/endpoints/10", Keyword: "required"},
+		{Location: "/version", Keyword: "properties/version/enum"},
+	})
+	require.Equal(t, []LintFinding{{Location: "/endpoints/10", Keyword: "required"}}, kept)
+	require.Len(t, ignored, 3)
+}
+
+func TestValidateConfig(t *testing.T) {
+	findings, err := ValidateConfig([]byte("version: 3\nname: 42\ncache_ttl: 3s\n"), []byte(testSchema))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	require.Equal(t, "/cache_ttl", findings[0].Location)
+	require.Equal(t, severityWarning, findings[0].Severity)
+	require.Equal(t, 3, findings[0].Line)
+	require.Equal(t, "/name", findings[1].Location)
+	require.Equal(t, severityError, findings[1].Severity)
+	require.Equal(t, 2, findings[1].Line)
+
+	findings, err = ValidateConfig([]byte(`{"version": 3}`), []byte(testSchema))
+	require.NoError(t, err)
+	require.Empty(t, findings)
+
+	_, err = ValidateConfig([]byte(`{"version": 3}`), []byte(`{"type": 42}`))
+	require.ErrorContains(t, err, "compiling the schema")
+
+	_, err = ValidateConfig([]byte(`{"version": `), []byte(testSchema))
+	require.ErrorContains(t, err, "decoding the configuration")
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// knownNamespaces are the extra_config namespaces of the official components. The binaries
+// add the ones of their own components with RegisterNamespaces
+var knownNamespaces = map[string]bool{}
+
+func init() {
+	RegisterNamespaces(
+		// lura
+		"github_com/luraproject/lura/router/gin",
+		"github.com/devopsfaith/krakend/http",
+		"github.com/devopsfaith/krakend/proxy",
+		"github.com/devopsfaith/krakend/proxy/plugin",
+		"github.com/devopsfaith/krakend/transport/http/client/executor",
+		"github.com/devopsfaith/krakend/transport/http/client/graphql",
+		"github_com/devopsfaith/krakend/transport/http/server/handler",
+		// official components
+		"router", "proxy", "backend/http", "backend/http/client", "backend/graphql", "backend/lambda",
+		"backend/grpc", "backend/soap", "backend/static-filesystem", "backend/conditional",
+		"backend/amqp/consumer", "backend/amqp/producer", "backend/pubsub/subscriber", "backend/pubsub/publisher",
+		"async/amqp", "grpc", "websocket", "server/static-filesystem", "server/virtualhost",
+		"auth/validator", "auth/signer", "auth/client-credentials", "auth/revoker", "auth/api-keys",
+		"auth/basic", "auth/gcp", "auth/ntlm",
+		"security/cors", "security/http", "security/bot-detector", "security/policies",
+		"qos/ratelimit/router", "qos/ratelimit/proxy", "qos/ratelimit/service", "qos/ratelimit/tiered",
+		"qos/circuit-breaker", "qos/http-cache",
+		"modifier/martian", "modifier/lua-proxy", "modifier/lua-backend", "modifier/lua-endpoint",
+		"modifier/jmespath", "modifier/body-generator", "modifier/response-body", "modifier/response-body-generator",
+		"modifier/request-body-generator",
+		"validation/json-schema", "validation/response-json-schema", "validation/cel",
+		"plugin/http-server", "plugin/http-client", "plugin/req-resp-modifier",
+		"telemetry/logging", "telemetry/gelf", "telemetry/logstash", "telemetry/metrics", "telemetry/opencensus",
+		"telemetry/opentelemetry", "telemetry/influx", "telemetry/newrelic", "telemetry/ganalytics",
+		"telemetry/instana", "telemetry/moesif",
+		"documentation/openapi",
+	)
+}
+
+// RegisterNamespaces declares the extra_config namespaces of the components of the binary, so
+// the check does not report them as unknown. It is not safe for concurrent use with the checks
+func RegisterNamespaces(namespaces ...string) {
+	for _, ns := range namespaces {
+		knownNamespaces[ns] = true
+	}
+}
+
+func isKnownNamespace(ns string) bool {
+	if knownNamespaces[ns] {
+		return true
+	}
+	for alias, namespace := range config.ExtraConfigAlias {
+		if ns == alias || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceUse is a namespace of the configuration not declared by any component
+type namespaceUse struct {
+	Namespace string
+	Location  string
+}
+
+// unknownNamespaces lists the namespaces of the extra_config sections of the service, the
+// endpoints, the async agents and their backends not declared by any known component
+func unknownNamespaces(v config.ServiceConfig) []namespaceUse {
+	var uses []namespaceUse
+	add := func(cfg config.ExtraConfig, pointer string) {
+		namespaces := make([]string, 0, len(cfg))
+		for ns := range cfg {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			if !isKnownNamespace(ns) {
+				uses = append(uses, namespaceUse{Namespace: ns, Location: pointer + "/extra_config/" + jsonPointerEscaper.Replace(ns)})
+			}
+		}
+	}
+	addBackends := func(backends []*config.Backend, pointer string) {
+		for i, b := range backends {
+			add(b.ExtraConfig, pointer+"/backend/"+strconv.Itoa(i))
+		}
+	}
+
+	add(v.ExtraConfig, "")
+	for i, e := range v.Endpoints {
+		pointer := "/endpoints/" + strconv.Itoa(i)
+		add(e.ExtraConfig, pointer)
+		addBackends(e.Backend, pointer)
+	}
+	for i, a := range v.AsyncAgents {
+		pointer := "/async_agent/" + strconv.Itoa(i)
+		add(a.ExtraConfig, pointer)
+		addBackends(a.Backend, pointer)
+	}
+	return uses
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_unknownNamespaces(t *testing.T) {
+	v := config.ServiceConfig{
+		ExtraConfig: config.ExtraConfig{"telemetry/logging": nil, "telemetry/loging": nil},
+		Endpoints: []*config.EndpointConfig{
+			{
+				ExtraConfig: config.ExtraConfig{"qos/ratelimit/router": nil},
+				Backend:     []*config.Backend{{ExtraConfig: config.ExtraConfig{"plugin/my-plugin": nil}}},
+			},
+		},
+	}
+	require.Equal(t, []namespaceUse{
+		{Namespace: "telemetry/loging", Location: "/extra_config/telemetry~1loging"},
+		{Namespace: "plugin/my-plugin", Location: "/endpoints/0/backend/0/extra_config/plugin~1my-plugin"},
+	}, unknownNamespaces(v))
+
+	RegisterNamespaces("plugin/my-plugin")
+	defer delete(knownNamespaces, "plugin/my-plugin")
+	require.Len(t, unknownNamespaces(v), 1)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// configOverride is a value replacing the one at a path of the configuration, declared as
+// path=value. The path is a list of dot separated keys and array indexes, like
+// endpoints.0.timeout, where \. escapes the dots of the keys. The value is decoded as JSON,
+// or used as a string when it is not valid JSON
+type configOverride struct {
+	Path  []string
+	Value interface{}
+}
+
+func parseConfigOverride(def string) (configOverride, error) {
+	path, value, ok := strings.Cut(def, "=")
+	if !ok || path == "" {
+		return configOverride{}, fmt.Errorf("invalid override %q: use path=value, like port=8081", def)
+	}
+	o := configOverride{Path: splitOverridePath(path), Value: value}
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err == nil {
+		o.Value = v
+	}
+	return o, nil
+}
+
+func splitOverridePath(path string) []string {
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
+// applyOverride sets the value at the path of the document. The path must exist, unless
+// create is set: then the missing keys are added, with an object for the intermediate ones,
+// and an index equal to the length of an array appends the value to it
+func applyOverride(doc interface{}, o configOverride, create bool) (interface{}, error) {
+	return o.set(doc, 0, create)
+}
+
+// set sets the value at the path of the node, from the key at the depth
+func (o configOverride) set(node interface{}, depth int, create bool) (interface{}, error) {
+	if depth == len(o.Path) {
+		return o.Value, nil
+	}
+	key, prefix := o.Path[depth], strings.Join(o.Path[:depth+1], ".")
+	switch t := node.(type) {
+	case map[string]interface{}:
+		child, ok := t[key]
+		if !ok {
+			if !create {
+				return nil, fmt.Errorf("%s does not exist. Use --set-create to add it", prefix)
+			}
+			child = map[string]interface{}{}
+		}
+		v, err := o.set(child, depth+1, create)
+		if err != nil {
+			return nil, err
+		}
+		t[key] = v
+		return t, nil
+
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i > len(t) || (i == len(t) && !create) {
+			return nil, fmt.Errorf("%s does not exist: %q is not an index of an array of %d item(s)", prefix, key, len(t))
+		}
+		if i == len(t) {
+			t = append(t, map[string]interface{}{})
+		}
+		v, err := o.set(t[i], depth+1, create)
+		if err != nil {
+			return nil, err
+		}
+		t[i] = v
+		return t, nil
+	}
+	return nil, fmt.Errorf("%s can not be set, as %s is not an object or an array", prefix, strings.Join(o.Path[:depth], "."))
+}
+
+// overrideConfig applies the overrides to the configuration as resolved by the parser,
+// returning a JSON source with the result. The positions of the lint findings refer to it
+func overrideConfig(p config.Parser, src *configSource, overrides []configOverride, create bool) (*configSource, error) {
+	data, err := parsedContent(p, src)
+	if err != nil {
+		return nil, src.Error(err)
+	}
+
+	doc, _, err := decodeDocument(src.Path, data)
+	if err != nil {
+		return nil, src.Error(err)
+	}
+	var errs []error
+	for _, o := range overrides {
+		v, err := applyOverride(doc, o, create)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		doc = v
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	overridden, err := json.MarshalIndent(doc, "", fmtIndent)
+	if err != nil {
+		return nil, err
+	}
+	return newTempConfigSource(src.Name, append(overridden, '\n'), "."+formatJSON)
+}
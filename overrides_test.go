@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseConfigOverride(t *testing.T) {
+	o, err := parseConfigOverride("port=8081")
+	require.NoError(t, err)
+	require.Equal(t, configOverride{Path: []string{"port"}, Value: 8081.0}, o)
+
+	o, err = parseConfigOverride(`extra_config.github_com/devopsfaith/krakend-gologging\.v2.level=DEBUG`)
+	require.NoError(t, err)
+	require.Equal(t, configOverride{Path: []string{"extra_config", "github_com/devopsfaith/krakend-gologging.v2", "level"}, Value: "DEBUG"}, o)
+
+	_, err = parseConfigOverride("port")
+	require.Error(t, err)
+}
+
+func Test_applyOverride(t *testing.T) {
+	doc := func() interface{} {
+		return map[string]interface{}{
+			"port":      8080.0,
+			"endpoints": []interface{}{map[string]interface{}{"endpoint": "/a"}},
+		}
+	}
+	set := func(def string, create bool) (interface{}, error) {
+		o, err := parseConfigOverride(def)
+		require.NoError(t, err)
+		return applyOverride(doc(), o, create)
+	}
+
+	v, err := set("endpoints.0.endpoint=/b", false)
+	require.NoError(t, err)
+	require.Equal(t, "/b", v.(map[string]interface{})["endpoints"].([]interface{})[0].(map[string]interface{})["endpoint"])
+
+	_, err = set("name=test", false)
+	require.ErrorContains(t, err, "--set-create")
+	_, err = set("endpoints.1.endpoint=/b", false)
+	require.Error(t, err)
+	_, err = set("port.number=1", true)
+	require.Error(t, err)
+
+	v, err = set("extra_config.router.disable_gzip=true", true)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"router": map[string]interface{}{"disable_gzip": true}}, v.(map[string]interface{})["extra_config"])
+	v, err = set("endpoints.1.endpoint=/b", true)
+	require.NoError(t, err)
+	require.Len(t, v.(map[string]interface{})["endpoints"], 2)
+}
+
+func TestCheck_overrides(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3, "name": "base"}`)
+	var parsed config.ServiceConfig
+	p := parserFunc(func(path string) (config.ServiceConfig, error) {
+		var err error
+		parsed, err = jsonParser.Parse(path)
+		return parsed, err
+	})
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: p, Overrides: []string{"name=test"}, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Equal(t, "test", parsed.Name)
+
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: p, Overrides: []string{"version=2"}, LintNoNetwork: true, EmbeddedSchema: testSchema})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageLint, res.Errors[0].Stage)
+
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: p, Overrides: []string{"port=8081"}})
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, stageParse, res.Errors[0].Stage)
+
+	_, err = Check(CheckOptions{ConfigFile: cfg, Parser: p, Overrides: []string{"port"}})
+	require.Error(t, err)
+}
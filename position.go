@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// sourcePositions locates the JSON pointers of a decoded document in its source
+type sourcePositions interface {
+	// Position returns the line and column (both starting at 1) where the pointer is declared.
+	// When the pointer is not in the document, the closest declared ancestor is used
+	Position(pointer string) (int, int)
+}
+
+// closestPointer returns the pointer itself or its closest ancestor accepted by the filter
+func closestPointer(pointer string, declared func(string) bool) string {
+	for !declared(pointer) {
+		i := strings.LastIndexByte(pointer, '/')
+		if i <= 0 {
+			return "/"
+		}
+		pointer = pointer[:i]
+	}
+	return pointer
+}
+
+// jsonPositions maps the JSON pointers of a document to the offset where they are declared:
+// the key of the object members and the first byte of the array items and the root value
+type jsonPositions struct {
+	data    []byte
+	offsets map[string]int
+}
+
+type jsonFrame struct {
+	pointer string
+	object  bool
+	wantKey bool
+	key     string
+	index   int
+}
+
+// newJSONPositions scans the document building the index. Scanning stops at the first syntax
+// error, keeping the positions found until then
+func newJSONPositions(data []byte) *jsonPositions {
+	p := &jsonPositions{data: data, offsets: map[string]int{}}
+	p.offsets["/"] = skipJSONSeparators(data, 0)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+	for {
+		off := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		start := skipJSONSeparators(data, off)
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		pointer := ""
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			switch {
+			case top.object && top.wantKey:
+				top.key, _ = tok.(string)
+				top.wantKey = false
+				p.offsets[top.pointer+"/"+jsonPointerEscaper.Replace(top.key)] = start
+				continue
+			case top.object:
+				pointer = top.pointer + "/" + jsonPointerEscaper.Replace(top.key)
+				top.wantKey = true
+			default:
+				pointer = top.pointer + "/" + strconv.Itoa(top.index)
+				top.index++
+				p.offsets[pointer] = start
+			}
+		}
+
+		if d, ok := tok.(json.Delim); ok {
+			stack = append(stack, &jsonFrame{pointer: pointer, object: d == '{', wantKey: d == '{'})
+		}
+	}
+	return p
+}
+
+func (p *jsonPositions) Position(pointer string) (int, int) {
+	return p.lineColumn(p.offsets[closestPointer(pointer, func(ptr string) bool {
+		_, ok := p.offsets[ptr]
+		return ok
+	})])
+}
+
+// yamlPositions maps the JSON pointers of a YAML document to the line and column reported
+// by the YAML decoder
+type yamlPositions map[string][2]int
+
+func (p yamlPositions) Position(pointer string) (int, int) {
+	pos := p[closestPointer(pointer, func(ptr string) bool {
+		_, ok := p[ptr]
+		return ok
+	})]
+	return pos[0], pos[1]
+}
+
+func (p *jsonPositions) lineColumn(off int) (int, int) {
+	if off > len(p.data) {
+		off = len(p.data)
+	}
+	line := bytes.Count(p.data[:off], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(p.data[:off], '\n') + 1
+	return line, utf8.RuneCount(p.data[lineStart:off]) + 1
+}
+
+func skipJSONSeparators(data []byte, off int) int {
+	for off < len(data) {
+		switch data[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
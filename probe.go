@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// backendHost is a backend host of the configuration, with the JSON pointer of its first use
+type backendHost struct {
+	Host     string
+	Location string
+	// Uses is the number of backends declaring the host
+	Uses int
+}
+
+// backendProbe is the result of probing a backend host
+type backendProbe struct {
+	backendHost
+	Err error
+}
+
+// backendHosts lists the hosts of the static backends of the endpoints and the async agents,
+// in order of appearance. The hosts resolved with a service discovery, like dns, are skipped
+func backendHosts(v config.ServiceConfig) []backendHost {
+	var hosts []backendHost
+	seen := map[string]int{}
+	add := func(b *config.Backend, location string) {
+		if b.SD != "" && b.SD != "static" {
+			return
+		}
+		for i, h := range b.Host {
+			if idx, ok := seen[h]; ok {
+				hosts[idx].Uses++
+				continue
+			}
+			seen[h] = len(hosts)
+			hosts = append(hosts, backendHost{Host: h, Location: location + "/host/" + strconv.Itoa(i), Uses: 1})
+		}
+	}
+	for i, e := range v.Endpoints {
+		for j, b := range e.Backend {
+			add(b, "/endpoints/"+strconv.Itoa(i)+"/backend/"+strconv.Itoa(j))
+		}
+	}
+	for i, a := range v.AsyncAgents {
+		for j, b := range a.Backend {
+			add(b, "/async_agent/"+strconv.Itoa(i)+"/backend/"+strconv.Itoa(j))
+		}
+	}
+	return hosts
+}
+
+// probeBackends sends a HEAD request to every host, with up to concurrency requests in flight.
+// Any response means the host is reachable, so only the failures to get one are returned
+func probeBackends(ctx context.Context, hosts []backendHost, timeout time.Duration, concurrency int) []backendProbe {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// the probe only checks the host answers, so its certificate is not relevant
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // skipcq: GSC-G402
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, host string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = probeBackend(ctx, client, host)
+		}(i, h.Host)
+	}
+	wg.Wait()
+
+	var failed []backendProbe
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, backendProbe{backendHost: hosts[i], Err: err})
+		}
+	}
+	return failed
+}
+
+func probeBackend(ctx context.Context, client *http.Client, host string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, host, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (p backendProbe) String() string {
+	msg := fmt.Sprintf("unreachable backend host %s: %s", p.Host, p.Err)
+	if p.Uses > 1 {
+		msg += fmt.Sprintf(" (used by %d backends)", p.Uses)
+	}
+	return msg
+}
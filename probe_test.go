@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backendHosts(t *testing.T) {
+	v := config.ServiceConfig{
+		Endpoints: []*config.EndpointConfig{
+			{Backend: []*config.Backend{{Host: []string{"http://a", "http://b"}}, {Host: []string{"http://a"}}}},
+			{Backend: []*config.Backend{{Host: []string{"backend.service.consul"}, SD: "dns"}}},
+		},
+		AsyncAgents: []*config.AsyncAgent{
+			{Backend: []*config.Backend{{Host: []string{"http://c"}, SD: "static"}}},
+		},
+	}
+	require.Equal(t, []backendHost{
+		{Host: "http://a", Location: "/endpoints/0/backend/0/host/0", Uses: 2},
+		{Host: "http://b", Location: "/endpoints/0/backend/0/host/1", Uses: 1},
+		{Host: "http://c", Location: "/async_agent/0/backend/0/host/0", Uses: 1},
+	}, backendHosts(v))
+}
+
+func Test_probeBackends(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer up.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := "http://" + l.Addr().String()
+	l.Close()
+
+	hosts := []backendHost{
+		{Host: up.URL, Location: "/endpoints/0/backend/0/host/0", Uses: 1},
+		{Host: down, Location: "/endpoints/1/backend/0/host/0", Uses: 3},
+	}
+	failed := probeBackends(context.Background(), hosts, time.Second, 1)
+	require.Len(t, failed, 1)
+	require.Equal(t, down, failed[0].Host)
+	require.Contains(t, failed[0].String(), "(used by 3 backends)")
+}
+
+func TestCheck_probeBackends(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := "http://" + l.Addr().String()
+	l.Close()
+
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	p := parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{Version: 3, Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/a", Method: "GET", Backend: []*config.Backend{{Host: []string{down}}}},
+		}}, nil
+	})
+
+	opts := CheckOptions{ConfigFile: cfg, Parser: p, ProbeBackends: true, ProbeTimeout: time.Second, ProbeConcurrency: 2}
+	res, err := Check(opts)
+	require.NoError(t, err)
+	require.Empty(t, res.Errors)
+	require.Len(t, res.Warnings, 1)
+	require.Equal(t, stageBackends, res.Warnings[0].Stage)
+
+	opts.WarnAsError = true
+	res, err = Check(opts)
+	require.NoError(t, err)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "/endpoints/0/backend/0/host/0", res.Errors[0].Location)
+
+	opts.ProbeConcurrency = 0
+	_, err = Check(opts)
+	require.ErrorContains(t, err, "invalid probe concurrency")
+}
@@ -0,0 +1,666 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krakendio/krakend-cobra/v2/dumper"
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+	formatJUnit = "junit"
+)
+
+var checkFormats = []string{formatText, formatJSON, formatSARIF, formatJUnit}
+
+const (
+	stageUsage       = "usage"
+	stageParse       = "parse"
+	stageLoad        = "load"
+	stageSchema      = "schema"
+	stageLint        = "lint"
+	stageEndpoints   = "endpoints"
+	stageEnv         = "env"
+	stageTemplate    = "template"
+	stageDump        = "dump"
+	stageRoutes      = "routes"
+	stageDeprecation = "deprecation"
+	stageBackends    = "backends"
+	stageNamespaces  = "namespaces"
+	stageTLS         = "tls"
+	stageTimeouts    = "timeouts"
+	stageAborted     = "aborted"
+)
+
+// Exit codes of the check command, so the scripts can tell the kind of failure
+const (
+	ExitCodeOK = 0
+	// ExitCodeFailure is used for the failures not covered by the rest of codes, like an
+	// error writing the results
+	ExitCodeFailure = 1
+	ExitCodeUsage   = 2
+	// ExitCodeParse reports a configuration that can not be loaded, rendered or parsed
+	ExitCodeParse  = 3
+	ExitCodeLint   = 4
+	ExitCodeRoutes = 5
+	// ExitCodeSchema reports a schema that can not be fetched or compiled, usually due to
+	// a network failure
+	ExitCodeSchema = 6
+	// ExitCodeAborted reports a check interrupted by the user, like the shells report the
+	// processes terminated by SIGINT
+	ExitCodeAborted = 130
+)
+
+var stageExitCodes = map[string]int{
+	stageUsage:       ExitCodeUsage,
+	stageLoad:        ExitCodeParse,
+	stageParse:       ExitCodeParse,
+	stageTemplate:    ExitCodeParse,
+	stageEnv:         ExitCodeParse,
+	stageDump:        ExitCodeParse,
+	stageSchema:      ExitCodeSchema,
+	stageLint:        ExitCodeLint,
+	stageDeprecation: ExitCodeLint,
+	stageEndpoints:   ExitCodeRoutes,
+	stageRoutes:      ExitCodeRoutes,
+	stageBackends:    ExitCodeLint,
+	stageNamespaces:  ExitCodeLint,
+	stageTLS:         ExitCodeLint,
+	stageTimeouts:    ExitCodeLint,
+	stageAborted:     ExitCodeAborted,
+}
+
+// checkExitCode returns the exit code for the first failure of the results. An interrupted check
+// takes precedence, as the rest of its failures may be caused by the interruption
+func checkExitCode(results []CheckResult) int {
+	for _, res := range results {
+		for _, e := range res.Errors {
+			if e.Stage == stageAborted {
+				return ExitCodeAborted
+			}
+		}
+	}
+	for _, res := range results {
+		if len(res.Errors) == 0 {
+			continue
+		}
+		if code, ok := stageExitCodes[res.Errors[0].Stage]; ok {
+			return code
+		}
+		return ExitCodeFailure
+	}
+	return ExitCodeOK
+}
+
+// CheckResult is the structured outcome of the check command
+type CheckResult struct {
+	ConfigFile   string       `json:"config_file"`
+	SchemaUsed   string       `json:"schema_used,omitempty"`
+	LintPassed   bool         `json:"lint_passed"`
+	RoutesTested bool         `json:"routes_tested"`
+	Routes       []RouteInfo  `json:"routes,omitempty"`
+	Errors       []CheckError `json:"errors"`
+	// Warnings are the findings not failing the check
+	Warnings []CheckError `json:"warnings,omitempty"`
+	// Ignored counts the lint findings suppressed by the ignore file
+	Ignored int `json:"ignored,omitempty"`
+	// Summary counts the components of the parsed configuration, when requested
+	Summary *ConfigSummary `json:"summary,omitempty"`
+	// Includes is the tree of the files included by the configuration, when requested
+	Includes *IncludeNode `json:"includes,omitempty"`
+	// Timings are the durations of the phases of the check, when requested
+	Timings []PhaseTiming `json:"timings,omitempty"`
+}
+
+// PhaseTiming is the time spent in a phase of the check
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"-"`
+	// Milliseconds is the duration, for the structured formats
+	Milliseconds float64 `json:"duration_ms"`
+}
+
+const (
+	phaseParse         = "parse"
+	phaseSchemaFetch   = "fetch schema"
+	phaseSchemaCompile = "compile schema"
+	phaseValidate      = "validate"
+	phaseDump          = "dump"
+	phaseRoutes        = "route test"
+	phaseProbe         = "probe backends"
+)
+
+// CheckError describes a single failure detected by the check command
+type CheckError struct {
+	Stage    string `json:"stage"`
+	Message  string `json:"message"`
+	Source   string `json:"source,omitempty"`
+	Location string `json:"location,omitempty"`
+	Keyword  string `json:"keyword,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	// Explanation and DocURL are set for the explained lint findings
+	Explanation string `json:"explanation,omitempty"`
+	DocURL      string `json:"doc_url,omitempty"`
+}
+
+// checkReporter records the outcome of the checks and writes the human oriented messages.
+// The messages are discarded when there is no output, so the structured formats are always
+// a single parseable document
+type checkReporter struct {
+	out       io.Writer
+	colors    bool
+	quiet     bool
+	verbosity int
+	timings   bool
+	maxErrors int
+	result    CheckResult
+}
+
+func newCheckReporter(out io.Writer, colors bool) *checkReporter {
+	if out == nil {
+		out = io.Discard
+	}
+	return &checkReporter{
+		out:    out,
+		colors: colors,
+		result: CheckResult{Errors: []CheckError{}},
+	}
+}
+
+func (r *checkReporter) Printf(format string, a ...interface{}) {
+	fmt.Fprintf(r.out, format, a...)
+}
+
+func (r *checkReporter) Println(a ...interface{}) {
+	fmt.Fprintln(r.out, a...)
+}
+
+// infof prints an informational message, unless the reporter is quiet
+func (r *checkReporter) infof(format string, a ...interface{}) {
+	if !r.quiet {
+		r.Printf(format, a...)
+	}
+}
+
+// debugf prints a diagnostic message when the verbosity reaches the received level
+func (r *checkReporter) debugf(level int, format string, a ...interface{}) {
+	if r.verbosity >= level {
+		r.Printf(format, a...)
+	}
+}
+
+// timing adds the duration to the phase, if the timings are enabled
+func (r *checkReporter) timing(phase string, d time.Duration) {
+	if !r.timings {
+		return
+	}
+	for i := range r.result.Timings {
+		if r.result.Timings[i].Phase == phase {
+			d += r.result.Timings[i].Duration
+			r.result.Timings[i] = newPhaseTiming(phase, d)
+			return
+		}
+	}
+	r.result.Timings = append(r.result.Timings, newPhaseTiming(phase, d))
+}
+
+func newPhaseTiming(phase string, d time.Duration) PhaseTiming {
+	return PhaseTiming{Phase: phase, Duration: d, Milliseconds: float64(d.Microseconds()) / 1000}
+}
+
+// printTimings prints the table of timings. They are explicitly requested, so they are
+// printed even when the reporter is quiet
+func (r *checkReporter) printTimings() {
+	if len(r.result.Timings) == 0 {
+		return
+	}
+	r.Println("Timings:")
+	for _, t := range r.result.Timings {
+		r.Printf("\t%-16s%s\n", t.Phase, t.Duration.Round(time.Microsecond))
+	}
+}
+
+func (r *checkReporter) errorMsg(content string) string {
+	return colorize(r.colors, dumper.ColorRed, content)
+}
+
+func (r *checkReporter) warnMsg(content string) string {
+	return colorize(r.colors, dumper.ColorYellow, content)
+}
+
+func (r *checkReporter) okMsg(content string) string {
+	return colorize(r.colors, dumper.ColorGreen, content)
+}
+
+// fail records the error and prints it in text mode
+func (r *checkReporter) fail(stage, source, title string, err error) {
+	ce := CheckError{Stage: stage, Message: title, Source: source}
+	if err == nil {
+		r.Println(r.errorMsg(title))
+	} else {
+		ce.Message = err.Error()
+		r.Println(r.errorMsg(title) + fmt.Sprintf("\t%s\n", sourceMsg(source, err.Error())))
+	}
+	r.add(ce)
+}
+
+// aborted reports the check was interrupted before completing it
+func (r *checkReporter) aborted(source string, err error) {
+	r.fail(stageAborted, source, "ERROR the check was aborted:", err)
+}
+
+// sourceMsg prefixes the printed message with the file it is about, so every failure names
+// its file when several of them are checked. Messages already naming it, like the ones of the
+// parser, are unchanged
+func sourceMsg(source, msg string) string {
+	if source == "" || strings.Contains(msg, source) {
+		return msg
+	}
+	return source + ": " + msg
+}
+
+// add records an error without printing it
+func (r *checkReporter) add(ce CheckError) {
+	r.result.Errors = append(r.result.Errors, ce)
+}
+
+// lintFailed records and prints all the findings of a failed schema validation
+func (r *checkReporter) lintFailed(source string, findings []LintFinding) {
+	r.Println(r.errorMsg(fmt.Sprintf("ERROR linting the configuration file: %d error(s) found", len(findings))))
+	for i, f := range findings {
+		if r.maxErrors == 0 || i < r.maxErrors {
+			r.printFinding(source, f)
+		}
+		r.add(lintCheckError(source, f))
+	}
+	if r.maxErrors > 0 && len(findings) > r.maxErrors {
+		r.Printf("\t... and %d more\n", len(findings)-r.maxErrors)
+	}
+}
+
+// lintWarned records the warnings of the schema validation and prints them, unless the
+// reporter is quiet
+func (r *checkReporter) lintWarned(source string, findings []LintFinding) {
+	if !r.quiet {
+		r.Println(r.warnMsg(fmt.Sprintf("WARNING linting the configuration file: %d warning(s) found", len(findings))))
+	}
+	for _, f := range findings {
+		if !r.quiet {
+			r.printFinding(source, f)
+		}
+		r.result.Warnings = append(r.result.Warnings, lintCheckError(source, f))
+	}
+}
+
+// semanticIssue is a finding of the semantic checks of the configuration
+type semanticIssue struct {
+	CheckError
+	// Text is the printed description. It defaults to the source, the location and the message
+	Text string
+	// Error fails the check even when the warnings are not reported as errors
+	Error bool
+}
+
+// semanticIssues records and prints the issues found by a semantic check under the title, as
+// warnings, or as errors when warnAsError is set. The title is prefixed by WARNING or, if any
+// issue is recorded as an error, by ERROR. A quiet reporter only prints the errors
+func (r *checkReporter) semanticIssues(stage, source, title string, issues []semanticIssue, warnAsError bool) {
+	failed := warnAsError
+	for _, i := range issues {
+		failed = failed || i.Error
+	}
+	switch {
+	case failed:
+		r.Println(r.errorMsg("ERROR " + title))
+	case !r.quiet:
+		r.Println(r.warnMsg("WARNING " + title))
+	}
+
+	for _, i := range issues {
+		text := i.Text
+		if text == "" {
+			text = sourceMsg(source, i.Location+": "+i.Message)
+		}
+		if i.Error || warnAsError || !r.quiet {
+			r.Printf("\t%s\n", text)
+		}
+
+		ce := i.CheckError
+		ce.Stage, ce.Source = stage, source
+		if i.Error || warnAsError {
+			r.add(ce)
+		} else {
+			r.result.Warnings = append(r.result.Warnings, ce)
+		}
+	}
+}
+
+// envUnset records and prints the references to unset environment variables, as errors or warnings
+func (r *checkReporter) envUnset(source string, refs []envReference, asError bool) {
+	issues := make([]semanticIssue, len(refs))
+	for i, ref := range refs {
+		msg := "environment variable '" + ref.Name + "' is not set"
+		text := fmt.Sprintf("%s: %s: %s", source, ref.Location, msg)
+		switch {
+		case ref.Line > 0 && ref.Location != "":
+			text = fmt.Sprintf("%s:%d:%d: %s: %s", source, ref.Line, ref.Column, ref.Location, msg)
+		case ref.Line > 0:
+			text = fmt.Sprintf("%s:%d:%d: %s", source, ref.Line, ref.Column, msg)
+		}
+		issues[i] = semanticIssue{
+			CheckError: CheckError{Message: msg, Location: ref.Location, Keyword: "env", Line: ref.Line, Column: ref.Column},
+			Text:       text,
+		}
+	}
+	r.semanticIssues(stageEnv, source, fmt.Sprintf("checking the environment: %d unset variable(s) found", len(refs)), issues, asError)
+}
+
+// schemaStale records and prints that the embedded schema targets another version of KrakenD,
+// as an error or a warning. A quiet reporter does not print the warning
+func (r *checkReporter) schemaStale(schemaVersion, binaryVersion string, asError bool) {
+	msg := fmt.Sprintf("the embedded schema is for KrakenD %s but this binary is %s. Use --lint to validate against the online schema", schemaVersion, binaryVersion)
+	ce := CheckError{Stage: stageSchema, Message: msg, Source: "embedded"}
+	if asError {
+		r.Println(r.errorMsg("ERROR checking the embedded schema:") + fmt.Sprintf("\t%s\n", msg))
+		r.add(ce)
+		return
+	}
+	if !r.quiet {
+		r.Println(r.warnMsg("WARNING checking the embedded schema:") + fmt.Sprintf("\t%s\n", msg))
+	}
+	r.result.Warnings = append(r.result.Warnings, ce)
+}
+
+// tolerantJSONApplied records the non-strict JSON removed before linting as a warning, and
+// prints it unless the reporter is quiet
+func (r *checkReporter) tolerantJSONApplied(source string, changes tolerantJSONChanges) {
+	msg := fmt.Sprintf("%d comment(s) and %d trailing comma(s) removed before linting", changes.Comments, changes.TrailingCommas)
+	if !r.quiet {
+		r.Println(r.warnMsg("WARNING tolerating the non-strict JSON:") + fmt.Sprintf("\t%s\n", sourceMsg(source, msg)))
+	}
+	r.result.Warnings = append(r.result.Warnings, CheckError{Stage: stageLint, Message: msg, Source: source})
+}
+
+// deprecatedKeysUsed records and prints the uses of deprecated keys, as errors or warnings
+func (r *checkReporter) deprecatedKeysUsed(source string, uses []deprecatedUse, asError bool) {
+	issues := make([]semanticIssue, len(uses))
+	for i, u := range uses {
+		msg := fmt.Sprintf("deprecated since KrakenD %s. Use %s instead", u.Key.Since, u.Key.Replacement)
+		issues[i] = semanticIssue{CheckError: CheckError{Message: msg, Location: u.Location, Keyword: "deprecated"}}
+	}
+	r.semanticIssues(stageDeprecation, source, fmt.Sprintf("checking the deprecations: %d deprecated key(s) found", len(uses)), issues, asError)
+}
+
+// backendsUnreachable records and prints the backend hosts not answering the probes, as errors
+// or warnings
+func (r *checkReporter) backendsUnreachable(source string, probes []backendProbe, asError bool) {
+	issues := make([]semanticIssue, len(probes))
+	for i, p := range probes {
+		issues[i] = semanticIssue{CheckError: CheckError{Message: p.String(), Location: p.Location}}
+	}
+	r.semanticIssues(stageBackends, source, fmt.Sprintf("probing the backends: %d unreachable host(s) found", len(probes)), issues, asError)
+}
+
+// backendHostsInvalid records and prints the backends without hosts, as errors, and the
+// repeated hosts, as errors or warnings
+func (r *checkReporter) backendHostsInvalid(source string, issues []backendHostIssue, asError bool) {
+	found := make([]semanticIssue, len(issues))
+	for i, issue := range issues {
+		found[i] = semanticIssue{
+			CheckError: CheckError{Message: issue.String(), Location: issue.Location()},
+			Text:       sourceMsg(source, issue.String()),
+			Error:      issue.Duplicate == "",
+		}
+	}
+	r.semanticIssues(stageBackends, source, fmt.Sprintf("validating the backend hosts: %d issue(s) found", len(issues)), found, asError)
+}
+
+// unknownNamespacesUsed records and prints the namespaces not declared by any component, as
+// errors or warnings
+func (r *checkReporter) unknownNamespacesUsed(source string, uses []namespaceUse, asError bool) {
+	issues := make([]semanticIssue, len(uses))
+	for i, u := range uses {
+		msg := fmt.Sprintf("no component of this binary uses the namespace %s, so it is ignored", u.Namespace)
+		issues[i] = semanticIssue{CheckError: CheckError{Message: msg, Location: u.Location}}
+	}
+	r.semanticIssues(stageNamespaces, source, fmt.Sprintf("checking the namespaces: %d unknown namespace(s) found", len(uses)), issues, asError)
+}
+
+// tlsFilesInvalid records and prints the key pairs of the TLS configuration that can not be
+// loaded, as errors or warnings
+func (r *checkReporter) tlsFilesInvalid(source string, issues []tlsIssue, asError bool) {
+	found := make([]semanticIssue, len(issues))
+	for i, issue := range issues {
+		found[i] = semanticIssue{CheckError: CheckError{Message: issue.Err.Error(), Location: issue.Location}}
+	}
+	r.semanticIssues(stageTLS, source, fmt.Sprintf("checking the TLS files: %d invalid key pair(s) found", len(issues)), found, asError)
+}
+
+// timeoutsInverted records and prints the timeouts larger than the ones enclosing them, as
+// errors or warnings
+func (r *checkReporter) timeoutsInverted(source string, issues []timeoutIssue, asError bool) {
+	found := make([]semanticIssue, len(issues))
+	for i, issue := range issues {
+		found[i] = semanticIssue{
+			CheckError: CheckError{Message: issue.String(), Location: issue.Location},
+			Text:       sourceMsg(source, issue.String()),
+		}
+	}
+	r.semanticIssues(stageTimeouts, source, fmt.Sprintf("checking the timeouts: %d inverted timeout(s) found", len(issues)), found, asError)
+}
+
+// templateFailed records and prints the errors found in the templates
+func (r *checkReporter) templateFailed(errs []TemplateError) {
+	r.Println(r.errorMsg(fmt.Sprintf("ERROR checking the templates: %d error(s) found", len(errs))))
+	for _, e := range errs {
+		switch {
+		case e.Column > 0:
+			r.Printf("\t%s:%d:%d: %s\n", e.File, e.Line, e.Column, e.Message)
+		case e.Line > 0:
+			r.Printf("\t%s:%d: %s\n", e.File, e.Line, e.Message)
+		default:
+			r.Printf("\t%s: %s\n", e.File, e.Message)
+		}
+		r.add(CheckError{Stage: stageTemplate, Message: e.Message, Source: e.File, Line: e.Line, Column: e.Column})
+	}
+}
+
+// lintIgnored records the number of suppressed findings, printing them with the first verbosity level
+func (r *checkReporter) lintIgnored(source string, findings []LintFinding) {
+	r.result.Ignored += len(findings)
+	r.debugf(1, "%d lint finding(s) ignored\n", len(findings))
+	if r.verbosity >= 1 {
+		for _, f := range findings {
+			r.printFinding(source, f)
+		}
+	}
+}
+
+func (r *checkReporter) printFinding(source string, f LintFinding) {
+	if f.Line > 0 {
+		r.Printf("\t%s:%d:%d: %s [%s]: %s\n", source, f.Line, f.Column, f.Location, f.Keyword, f.Message)
+	} else {
+		r.Printf("\t%s\n", sourceMsg(source, fmt.Sprintf("%s [%s]: %s", f.Location, f.Keyword, f.Message)))
+	}
+	if f.Explanation != "" {
+		r.Printf("\t\t%s. See %s\n", f.Explanation, f.DocURL)
+	}
+}
+
+func lintCheckError(source string, f LintFinding) CheckError {
+	return CheckError{
+		Stage:    stageLint,
+		Message:  f.Message,
+		Source:   source,
+		Location: f.Location,
+		Keyword:  f.Keyword,
+		Line:     f.Line,
+		Column:   f.Column,
+
+		Explanation: f.Explanation,
+		DocURL:      f.DocURL,
+	}
+}
+
+// endpointsCollide records and prints all the endpoint collisions
+func (r *checkReporter) endpointsCollide(source string, collisions []endpointCollision) {
+	r.Println(r.errorMsg(fmt.Sprintf("ERROR validating the endpoints: %d collision(s) found", len(collisions))))
+	for _, c := range collisions {
+		r.Printf("\t%s\n", sourceMsg(source, c.String()))
+		r.add(CheckError{
+			Stage:    stageEndpoints,
+			Message:  c.String(),
+			Source:   source,
+			Location: "/endpoints/" + strconv.Itoa(c.Second),
+		})
+	}
+}
+
+// dumpFailed records and prints all the issues found by the dumper, joined in its error
+func (r *checkReporter) dumpFailed(source string, err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	if len(errs) == 1 {
+		r.fail(stageDump, source, "ERROR checking the configuration file:", errs[0])
+		return
+	}
+	r.Println(r.errorMsg(fmt.Sprintf("ERROR checking the configuration file: %d error(s) found", len(errs))))
+	for _, e := range errs {
+		r.Printf("\t%s\n", sourceMsg(source, e.Error()))
+		r.add(CheckError{Stage: stageDump, Message: e.Error(), Source: source})
+	}
+}
+
+// printIncludes records and prints the tree of included files. It is explicitly requested, so
+// it is printed even when the reporter is quiet
+func (r *checkReporter) printIncludes(root *IncludeNode) {
+	r.result.Includes = root
+	r.Println("Includes:")
+	var printNode func(n *IncludeNode, depth int)
+	printNode = func(n *IncludeNode, depth int) {
+		status := n.Kind
+		if n.Missing {
+			status += ", " + r.errorMsg("missing")
+		}
+		r.Printf("\t%s%s (%s)\n", strings.Repeat("  ", depth), n.Path, status)
+		for _, child := range n.Includes {
+			printNode(child, depth+1)
+		}
+	}
+	printNode(root, 0)
+}
+
+// printSummary prints the summary of the parsed configuration, if it was requested
+func (r *checkReporter) printSummary() {
+	s := r.result.Summary
+	if s == nil {
+		return
+	}
+	r.infof("%d endpoint(s), %d backend(s), %d async agent(s) and %d plugin(s)\n", s.Endpoints, s.Backends, s.AsyncAgents, len(s.Plugins))
+	if len(s.Plugins) > 0 {
+		r.infof("\tPlugins: %s\n", strings.Join(s.Plugins, ", "))
+	}
+}
+
+// printRoutes prints the registered routes, if they were listed
+func (r *checkReporter) printRoutes() {
+	if r.result.Routes == nil {
+		return
+	}
+	r.Printf("Registered routes: %d\n", len(r.result.Routes))
+	for _, route := range r.result.Routes {
+		if len(route.Backends) == 0 {
+			r.Printf("\t%s\t%s\n", route.Method, route.Path)
+			continue
+		}
+		r.Printf("\t%s\t%s\t-> %s\n", route.Method, route.Path, strings.Join(route.Backends, ", "))
+	}
+}
+
+// printCheckSummary prints the outcome of every checked file and the aggregated counters. It
+// is the result of the text format, so it is written to stdout, unlike the messages of the checks
+func printCheckSummary(cmd *cobra.Command, results []CheckResult) {
+	w := cmd.OutOrStdout()
+	colors := useColorsFor(w)
+	failedMsg, passedMsg := colorize(colors, dumper.ColorRed, "FAILED"), colorize(colors, dumper.ColorGreen, "OK")
+	failed := 0
+	fmt.Fprintln(w)
+	for _, res := range results {
+		if len(res.Errors) > 0 {
+			failed++
+			fmt.Fprintf(w, "%s\t%s\n", failedMsg, res.ConfigFile)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", passedMsg, res.ConfigFile)
+	}
+	fmt.Fprintf(w, "%d OK, %d FAILED\n", len(results)-failed, failed)
+}
+
+// writeCheckResults encodes the results for the structured formats. For json, the results are
+// always encoded as a list, whatever the number of checked files. It returns false if the
+// encoding failed
+func writeCheckResults(cmd *cobra.Command, format string, results []CheckResult) bool {
+	if format == formatText {
+		return true
+	}
+
+	var w io.Writer = cmd.OutOrStdout()
+	if checkReportFile != "" {
+		f, err := createReportFile(checkReportFile)
+		if err != nil {
+			cmd.PrintErrln(errorMsg("ERROR writing the report file:") + fmt.Sprintf("\t%s\n", err.Error()))
+			return false
+		}
+		defer f.Close()
+		w = f
+	}
+
+	var err error
+	switch format {
+	case formatJSON:
+		enc := newJSONEncoder(w)
+		err = enc.Encode(results)
+	case formatSARIF:
+		err = writeSARIF(w, results)
+	case formatJUnit:
+		err = writeJUnit(w, results)
+	}
+	if err != nil {
+		cmd.PrintErrln(errorMsg("ERROR encoding the result:") + fmt.Sprintf("\t%s\n", err.Error()))
+		return false
+	}
+	return true
+}
+
+// createReportFile creates the report file and its missing directories
+func createReportFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func jsonPointer(tokens []string) string {
+	if len(tokens) == 0 {
+		return "/"
+	}
+	var p string
+	for _, t := range tokens {
+		p += "/" + jsonPointerEscaper.Replace(t)
+	}
+	return p
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeCheckResults(t *testing.T) {
+	origReport := checkReportFile
+	defer func() { checkReportFile = origReport }()
+	checkReportFile = ""
+
+	ok := CheckResult{ConfigFile: "ok.json", LintPassed: true, Errors: []CheckError{}}
+	bad := CheckResult{ConfigFile: "bad.json", Errors: []CheckError{{Stage: stageLint, Message: "value must be 3", Location: "/version"}}}
+
+	for name, tc := range map[string]struct {
+		format  string
+		results []CheckResult
+	}{
+		"single result":    {format: formatJSON, results: []CheckResult{bad}},
+		"several results":  {format: formatJSON, results: []CheckResult{ok, bad}},
+		"no results":       {format: formatJSON, results: []CheckResult{}},
+		"text writes none": {format: formatText, results: []CheckResult{ok, bad}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&out)
+			require.True(t, writeCheckResults(cmd, tc.format, tc.results))
+
+			if tc.format == formatText {
+				require.Empty(t, out.String())
+				return
+			}
+			// the results are a list whatever their number
+			var got []CheckResult
+			require.NoError(t, json.Unmarshal(out.Bytes(), &got), out.String())
+			require.Equal(t, tc.results, got)
+		})
+	}
+}
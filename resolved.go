@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var (
+	// routeParamPattern matches the parameters of an endpoint path, as rewritten for the router
+	routeParamPattern = regexp.MustCompile(`:([\w\-\.]+)`)
+	// urlKeyPattern matches the parameters of a backend url pattern, as rewritten by the parser
+	urlKeyPattern = regexp.MustCompile(`\{\{\.([\w\-\.]+)\}\}`)
+)
+
+// resolvedConfig converts a parsed configuration into a generic document using the same keys
+// as the configuration files. Zero values and the fields not bound to a configuration key are
+// omitted and durations are rendered as strings (e.g. 1m30s)
+func resolvedConfig(cfg config.ServiceConfig) map[string]interface{} {
+	v, ok := toGeneric(reflect.ValueOf(cfg))
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return v.(map[string]interface{})
+}
+
+// toGeneric returns the generic representation of the value and false if it is a zero value
+func toGeneric(v reflect.Value) (interface{}, bool) {
+	if v.Type() == durationType {
+		d := time.Duration(v.Int())
+		return d.String(), d != 0
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return toGeneric(v.Elem())
+
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if !f.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			if fv, ok := toGeneric(v.Field(i)); ok {
+				m[name] = fv
+			}
+		}
+		return m, len(m) > 0
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil, false
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			// keep the explicit zero values declared in maps, as they are part of the configuration
+			fv, _ := toGeneric(iter.Value())
+			m[iter.Key().String()] = fv
+		}
+		return m, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil, false
+		}
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i], _ = toGeneric(v.Index(i))
+		}
+		return l, true
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, false
+	}
+
+	return v.Interface(), !v.IsZero()
+}
+
+// deployableConfig is the resolved configuration with the parameters of the endpoint paths and
+// of the url patterns of their backends declared as in the configuration files, as the parser
+// rewrites them for the router and the proxies. The parser can load it again
+func deployableConfig(cfg config.ServiceConfig) map[string]interface{} {
+	doc := resolvedConfig(cfg)
+	endpoints, _ := doc["endpoints"].([]interface{})
+	for _, e := range endpoints {
+		endpoint, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := endpoint["endpoint"].(string)
+		route, query, hasQuery := strings.Cut(path, "?")
+		// the url keys are the parameters with the first letter in upper case
+		params := map[string]string{}
+		route = routeParamPattern.ReplaceAllStringFunc(route, func(m string) string {
+			name := m[1:]
+			params[strings.ToUpper(name[:1])+name[1:]] = name
+			return "{" + name + "}"
+		})
+		if hasQuery {
+			route += "?" + query
+		}
+		if path != "" {
+			endpoint["endpoint"] = route
+		}
+
+		backends, _ := endpoint["backend"].([]interface{})
+		for _, b := range backends {
+			backend, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			pattern, ok := backend["url_pattern"].(string)
+			if !ok {
+				continue
+			}
+			backend["url_pattern"] = urlKeyPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+				key := m[3 : len(m)-2]
+				if name, ok := params[key]; ok {
+					return "{" + name + "}"
+				}
+				// the params of the sequential proxy, like resp0_id
+				return "{" + strings.ToLower(key[:1]) + key[1:] + "}"
+			})
+		}
+	}
+	return doc
+}
+
+// writeResolvedConfig writes the deployable configuration as JSON to the file, once the parser
+// loads it like the original one, so it can replace it
+func writeResolvedConfig(p config.Parser, cfg config.ServiceConfig, path string) error {
+	data, err := json.MarshalIndent(deployableConfig(cfg), "", fmtIndent)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	src, err := newTempConfigSource(path, data, "."+formatJSON)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if _, err := p.Parse(src.Path); err != nil {
+		return fmt.Errorf("the parser can not load the resolved configuration: %w", src.Error(err))
+	}
+	return writeFileAtomic(path, data)
+}
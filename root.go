@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+	"runtime"
+	"time"
 
 	"github.com/luraproject/lura/v2/config"
 	"github.com/luraproject/lura/v2/core"
@@ -14,48 +16,134 @@ import (
 var IsTTY = isatty.IsTerminal(os.Stderr.Fd())
 
 var (
-	cfgFile              string
-	debug                int
-	port                 int
-	checkGinRoutes       bool
-	checkDebug           int
-	lintCurrentSchema    bool
-	lintCustomSchemaPath string
-	lintNoNetwork        bool
-	rawEmbedSchema       string
-	rulesToExclude       string
-	rulesToExcludePath   string
-	severitiesToInclude  = "CRITICAL,HIGH,MEDIUM,LOW"
-	formatTmpl           string
-	parser               config.Parser
-	run                  func(config.ServiceConfig)
+	cfgFile               string
+	checkConfigFiles      []string
+	debug                 int
+	colorMode             = colorAuto
+	jsonIndent            = jsonIndentAuto
+	configDir             string
+	port                  int
+	runTimeout            = time.Second
+	checkPort             = -1
+	checkBuildOnly        bool
+	checkFailFast         = true
+	checkTimings          bool
+	schemaBaseURI         string
+	schemaBaseURL         string
+	checkReportFile       string
+	checkIncludeRoot      string
+	checkConfigInline     string
+	checkGinRoutes        bool
+	checkDebug            int
+	lintCurrentSchema     bool
+	lintCustomSchemaPaths []string
+	lintNoNetwork         bool
+	checkNoAutoSchema     bool
+	checkOutputFormat     = formatText
+	schemaCacheTTL        = 24 * time.Hour
+	schemaNoCache         bool
+	schemaVersion         string
+	schemaProxy           string
+	schemaTimeout         = 10 * time.Second
+	schemaRetries         = 2
+	schemaRetryBackoff    = 500 * time.Millisecond
+	schemaHeaders         []string
+	lintStrict            bool
+	lintFragment          string
+	lintExplain           bool
+	lintMaxErrors         = 50
+	lintWarnAsError       bool
+	lintIgnoreFile        string
+	lintTolerantJSON      bool
+	checkEnv              bool
+	checkDeprecations     bool
+	checkTargetVersion    string
+	checkWatch            bool
+	checkRaw              bool
+	checkNamespaces       bool
+	checkConfigFormat     string
+	checkNoLint           bool
+	checkSummary          bool
+	checkExitZero         bool
+	checkJobs             = runtime.GOMAXPROCS(0)
+	checkProbeBackends    bool
+	checkProbeTimeout     = 3 * time.Second
+	checkProbeConcurrency = 8
+	checkTemplate         bool
+	checkTraceIncludes    bool
+	checkOverrides        []string
+	checkOverridesCreate  bool
+	checkChanged          bool
+	checkMaxConfigSize    = int64(64 << 20)
+	checkOutputConfig     string
+	checkChangedBase      = "main"
+	checkQuiet            bool
+	checkVerbose          int
+	checkDumpFormat       = formatText
+	checkDumpOnly         bool
+	checkPrintSource      bool
+	checkListRoutes       bool
+	rawEmbedSchema        string
+	rulesToExclude        string
+	rulesToExcludePath    string
+	severitiesToInclude   = "CRITICAL,HIGH,MEDIUM,LOW"
+	auditEnabledRules     string
+	auditDisabledRules    string
+	auditSeverityGate     string
+	auditListRules        bool
+	formatTmpl            string
+	auditOutput           = formatText
+	initTemplateName      = initDefaultTemplate
+	lintRulesFormat       = formatText
+	schemaDiffFormat      = formatText
+	initListTemplates     bool
+	parser                config.Parser
+	run                   func(config.ServiceConfig)
 
 	goSum           = "./go.sum"
 	goVersion       = core.GoVersion
 	libcVersion     = core.GlibcVersion
 	checkDumpPrefix = "\t"
 	gogetEnabled    = false
+	fmtStdout       = false
+	fmtCheck        = false
+	diffConfigB     string
+	diffFormat      = formatText
+	schemaOut       string
+	schemaIndent    = fmtIndent
+	schemaUnlink    = false
+	initOut         = "krakend.json"
+	initForce       = false
+	initLinkSchema  = false
 
-	DefaultRoot    Root
-	RootCommand    Command
-	RunCommand     Command
-	CheckCommand   Command
-	PluginCommand  Command
-	VersionCommand Command
-	AuditCommand   Command
+	DefaultRoot      Root
+	RootCommand      Command
+	RunCommand       Command
+	CheckCommand     Command
+	PluginCommand    Command
+	VersionCommand   Command
+	AuditCommand     Command
+	FmtCommand       Command
+	DiffCommand      Command
+	SchemaCommand    Command
+	InitCommand      Command
+	LintRulesCommand Command
 
 	rootCmd = &cobra.Command{
 		Use:   "krakend",
 		Short: "KrakenD is a high-performance API gateway that helps you publish, secure, control, and monitor your services",
+		Long:  "KrakenD is a high-performance API gateway that helps you publish, secure, control, and monitor your services.\n\nThe flags of the check, fmt and audit commands not passed default to the KRAKEND_<COMMAND>_<FLAG> env vars, like KRAKEND_CHECK_SCHEMA_VERSION,\nand then to the $HOME/.config/krakend/cli.yaml file. Its top-level keys set the global flags and\nits sections, named after the commands, the flags of the commands",
+
+		PersistentPreRunE: rootPreRun,
 	}
 
 	checkCmd = &cobra.Command{
 		Use:     "check",
 		Short:   "Validates that the configuration file is valid.",
-		Long:    "Validates that the active configuration file has a valid syntax to run the service.\nChange the configuration file by using the --config flag",
-		Run:     checkFunc,
+		Long:    "Validates that the active configuration file has a valid syntax to run the service.\nChange the configuration file by using the --config flag\n\nExit codes: 0 valid, 2 wrong usage, 3 parsing error, 4 lint or semantic error, like a backend without hosts or an unreadable TLS key,\n5 routes error, 6 schema fetching or compilation error and 1 for any other failure\n\nThe flags not passed default to the values of a .krakend-check.yaml file in the working directory,\nkeyed by their long names, and then to the check section of $HOME/.config/krakend/cli.yaml.\nThe KRAKEND_CHECK_<FLAG> env vars, like KRAKEND_CHECK_GIN_ROUTES=1, take precedence over both files",
+		RunE:    checkFunc,
 		Aliases: []string{"validate"},
-		Example: "krakend check -d -l -c config.json",
+		Example: "krakend check -d -l -c config.json\nkrakend check -l -c \"configs/*.json\"",
 	}
 
 	runCmd = &cobra.Command{
@@ -82,15 +170,112 @@ var (
 		Example: "krakend version",
 	}
 
+	fmtCmd = &cobra.Command{
+		Use:     "fmt",
+		Short:   "Formats the configuration file.",
+		Long:    "Rewrites the configuration file as canonical JSON, with sorted keys and a stable indentation.",
+		Run:     fmtFunc,
+		Example: "krakend fmt -c krakend.json\nkrakend fmt --check -c \"configs/*.json\"",
+	}
+
+	diffCmd = &cobra.Command{
+		Use:     "diff",
+		Short:   "Compares two resolved configurations.",
+		Long:    "Parses two configurations and shows the differences between the resolved settings.\nIt exits with an error when they differ.",
+		Run:     diffFunc,
+		Example: "krakend diff -c krakend.json -b krakend-staging.json",
+	}
+
+	schemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Manages the KrakenD JSON schema.",
+		Long:  "Manages the KrakenD JSON schema used for linting the configuration.",
+	}
+
+	schemaFetchCmd = &cobra.Command{
+		Use:     "fetch",
+		Short:   "Downloads the official JSON schema.",
+		Long:    "Downloads the official KrakenD JSON schema and saves it, once it is known to compile, so it can be used offline with krakend check --lint-schema.",
+		RunE:    schemaFetchFunc,
+		Example: "krakend schema fetch --version 2.6 --out krakend-2.6.json",
+	}
+
+	schemaDiffCmd = &cobra.Command{
+		Use:     "diff",
+		Short:   "Compares the embedded JSON schema with the online one.",
+		Long:    "Downloads the official KrakenD JSON schema of the version of this binary and lists the constraints it adds,\nremoves or changes compared to the schema embedded in the binary. It exits with 1 when they differ.",
+		RunE:    schemaDiffFunc,
+		Example: "krakend schema diff\nkrakend schema diff --version 2.6 --format json",
+	}
+
+	schemaPrintCmd = &cobra.Command{
+		Use:     "print",
+		Short:   "Prints the embedded JSON schema.",
+		Long:    "Writes the JSON schema embedded in the binary, used by krakend check --lint-no-network, to stdout.",
+		RunE:    schemaPrintFunc,
+		Example: "krakend schema print --indent \"    \" > krakend-embedded.json",
+	}
+
+	schemaLinkCmd = &cobra.Command{
+		Use:     "link",
+		Short:   "Links the configuration to its JSON schema.",
+		Long:    "Sets the top-level $schema property of the configuration to the official JSON schema of its version,\nso editors can validate and autocomplete it. The rest of the file is left untouched.",
+		RunE:    schemaLinkFunc,
+		Example: "krakend schema link -c krakend.json\nkrakend schema link --remove -c krakend.json",
+	}
+
+	initCmd = &cobra.Command{
+		Use:     "init",
+		Short:   "Creates a minimal configuration file.",
+		Long:    "Writes a starter configuration, with a single endpoint and backend by default, or one of the templates\ndemonstrating a common pattern. The generated file is checked right after writing it.",
+		RunE:    initFunc,
+		Example: "krakend init --out krakend.json\nkrakend init --template jwt-gateway --link-schema --force\nkrakend init --list-templates",
+	}
+
+	lintRulesCmd = &cobra.Command{
+		Use:     "lint-rules",
+		Short:   "Lists the constraints of the JSON schema.",
+		Long:    "Summarizes the types, the required properties and the accepted values the JSON schema declares for every\nlocation of the configuration. The schema is selected like in krakend check, using the embedded one by default.",
+		Run:     lintRulesFunc,
+		Example: "krakend lint-rules\nkrakend lint-rules --lint-schema ./my-schema.json -f json",
+	}
+
 	auditCmd = &cobra.Command{
 		Use:     "audit",
 		Short:   "Audits a KrakenD configuration.",
 		Long:    "Audits a KrakenD configuration.",
 		Run:     auditFunc,
-		Example: "krakend audit -i 1.1.1,1.1.2 -s CRITICAL -c krakend.json",
+		Example: "krakend audit -i 1.1.1,1.1.2 -s CRITICAL -c krakend.json\nkrakend audit -o json -c krakend.json",
 	}
 )
 
+// rootPreRun validates and applies the global flags before running any subcommand
+func rootPreRun(cmd *cobra.Command, args []string) error {
+	if err := applyCommandDefaults(cmd); err != nil {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		if cmd == checkCmd {
+			return checkUsageError(cmd, "ERROR applying the flag defaults:", err)
+		}
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+	if err := validateColorMode(cmd, args); err != nil {
+		return err
+	}
+	if err := validateJSONIndent(cmd, args); err != nil {
+		return err
+	}
+	if configDir == "" {
+		return nil
+	}
+	if err := applyConfigDir(configDir); err != nil {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return &ExitError{Code: ExitCodeUsage, Err: err}
+	}
+	return nil
+}
+
 func init() {
 	logo, err := base64.StdEncoding.DecodeString(encodedLogo)
 	if err != nil {
@@ -99,20 +284,98 @@ func init() {
 
 	cfgFlag := StringFlagBuilder(&cfgFile, "config", "c", "", "Path to the configuration file")
 	debugFlag := CountFlagBuilder(&debug, "debug", "d", "Enables the debug endpoint")
-	RootCommand = NewCommand(rootCmd)
+	colorFlag := StringFlagBuilder(&colorMode, "color", "", colorMode, "Colors the output: auto, always or never. With auto, the NO_COLOR env var disables the colors")
+	configDirFlag := StringFlagBuilder(&configDir, "config-dir", "", configDir, "Base directory of the flexible configuration. The relative FC_SETTINGS, FC_PARTIALS and FC_TEMPLATES paths are resolved against it and, when not set, its settings, partials and templates subdirectories are used")
+	jsonIndentFlag := IntFlagBuilder(&jsonIndent, "json-indent", "", jsonIndent, "Spaces indenting the JSON outputs, like the results and the dumps. Use 0 for a compact output. They are indented with 2 spaces in terminals and compacted otherwise by default")
+	RootCommand = NewCommand(rootCmd, colorFlag, configDirFlag, jsonIndentFlag)
+	RootCommand.AddConstraint(FlagCompletion("color", completeValues(colorModes...)))
 	RootCommand.Cmd.SetHelpTemplate(string(logo) + "Version: " + core.KrakendVersion + "\n\n" + rootCmd.HelpTemplate())
 
 	ginRoutesFlag := BoolFlagBuilder(&checkGinRoutes, "test-gin-routes", "t", false, "Tests the endpoint patterns against a real gin router on the selected port")
 	prefixFlag := StringFlagBuilder(&checkDumpPrefix, "indent", "i", checkDumpPrefix, "Indentation of the check dump")
+	dumpPrefixFlag := StringFlagBuilder(&checkDumpPrefix, "dump-prefix", "", checkDumpPrefix, "Prefix indenting the lines of the check dump. It can be empty for a flat dump")
+	lintDisabledFlag := BoolFlagBuilder(&checkNoLint, "no-lint", "", checkNoLint, "Skips the linting, ignoring the rest of the lint flags, while running the rest of the checks like the dump and the routes testing")
 	lintCurrentSchemaFlag := BoolFlagBuilder(&lintCurrentSchema, "lint", "l", lintCurrentSchema, "Enables the linting against the official KrakenD online JSON schema")
-	lintCustomSchemaFlag := StringFlagBuilder(&lintCustomSchemaPath, "lint-schema", "s", lintCustomSchemaPath, "Lint against a custom schema path or URL")
+	lintCustomSchemaFlag := StringArrayFlagBuilder(&lintCustomSchemaPaths, "lint-schema", "s", nil, "Lint against a custom schema path or URL, or - to read it from stdin. It can be repeated to layer more schemas on top of the first one, or of the official one when --lint, --lint-no-network or --schema-version is set")
 	lintNoNetworkFlag := BoolFlagBuilder(&lintNoNetwork, "lint-no-network", "n", lintNoNetwork, "Lint against the builtin Krakend JSON schema, no network is required")
+	lintNoAutoSchemaFlag := BoolFlagBuilder(&checkNoAutoSchema, "no-auto-schema", "", checkNoAutoSchema, "Skips the linting against the local schema referenced by the $schema property of the configuration, or the schema/krakend.json next to it, when no schema is selected with --lint, --lint-no-network, --lint-schema or --schema-version")
 	checkDebugFlag := CountFlagBuilder(&checkDebug, "debug", "d", "Information about how KrakenD is interpreting your configuration file")
-	CheckCommand = NewCommand(checkCmd, cfgFlag, checkDebugFlag, ginRoutesFlag, prefixFlag, lintCurrentSchemaFlag, lintCustomSchemaFlag, lintNoNetworkFlag)
-	CheckCommand.AddConstraint(MutuallyExclusive("lint", "lint-no-network", "lint-schema"))
+	schemaCacheTTLFlag := DurationFlagBuilder(&schemaCacheTTL, "schema-cache-ttl", "", schemaCacheTTL, "Time a downloaded schema is reused from the local cache")
+	schemaNoCacheFlag := BoolFlagBuilder(&schemaNoCache, "no-schema-cache", "", schemaNoCache, "Always download the schema, ignoring the cached copy")
+	schemaVersionFlag := StringFlagBuilder(&schemaVersion, "schema-version", "", schemaVersion, "Version (MAJOR.MINOR) of the official online schema to lint against")
+	schemaProxyFlag := StringFlagBuilder(&schemaProxy, "schema-proxy", "", schemaProxy, "Proxy URL used to download the schema. It takes precedence over the HTTP_PROXY, HTTPS_PROXY and NO_PROXY env vars")
+	schemaTimeoutFlag := DurationFlagBuilder(&schemaTimeout, "schema-timeout", "", schemaTimeout, "Timeout for downloading the schema, including retries (e.g. 3s, 500ms)")
+	schemaRetriesFlag := IntFlagBuilder(&schemaRetries, "schema-retries", "", schemaRetries, "Number of retries on transient failures while downloading the schema")
+	schemaHeaderFlag := StringArrayFlagBuilder(&schemaHeaders, "schema-header", "", nil, "Header added to the schema requests, with the format \"Name: Value\". It can be repeated")
+	lintStrictFlag := BoolFlagBuilder(&lintStrict, "strict", "", lintStrict, "Reports the properties not described by the schema as lint errors")
+	lintFragmentFlag := StringFlagBuilder(&lintFragment, "fragment", "", lintFragment, "JSON pointer of the only part of the configuration to lint, like /endpoints/0. It is validated against the part of the schema describing it")
+	lintMaxErrorsFlag := IntFlagBuilder(&lintMaxErrors, "max-errors", "", lintMaxErrors, "Maximum number of lint errors printed, summarizing the rest. Use 0 to print all of them")
+	lintExplainFlag := BoolFlagBuilder(&lintExplain, "explain", "", lintExplain, "Describes the lint findings, with a link to the documentation of the property")
+	lintWarnAsErrorFlag := BoolFlagBuilder(&lintWarnAsError, "warn-as-error", "", lintWarnAsError, "Reports the warnings, like the use of deprecated properties, as errors")
+	lintIgnoreFlag := StringFlagBuilder(&lintIgnoreFile, "lint-ignore", "", lintIgnoreFile, "Path to a file listing the lint findings to ignore, one JSON pointer or schema keyword per line")
+	checkEnvFlag := BoolFlagBuilder(&checkEnv, "check-env", "", checkEnv, "Reports the environment variables referenced by the configuration but not set. They fail the check only with --warn-as-error")
+	checkDeprecationsFlag := BoolFlagBuilder(&checkDeprecations, "check-deprecations", "", checkDeprecations, "Reports the deprecated keys used by the configuration, with their replacement. They fail the check only with --warn-as-error")
+	checkNamespacesFlag := BoolFlagBuilder(&checkNamespaces, "check-namespaces", "", checkNamespaces, "Reports the extra_config namespaces not used by any component of this binary, like a misspelled plugin. They fail the check only with --warn-as-error")
+	checkTargetVersionFlag := StringFlagBuilder(&checkTargetVersion, "target-version", "", checkTargetVersion, "Version (MAJOR.MINOR) of KrakenD the configuration is intended for, so only the keys deprecated by then are reported. The version of this binary is used by default")
+	checkWatchFlag := BoolFlagBuilder(&checkWatch, "watch", "", checkWatch, "Checks the configuration again every time it, its flexible configuration dirs, the custom schemas or the lint ignore file change")
+	checkTemplateFlag := BoolFlagBuilder(&checkTemplate, "template-check", "", checkTemplate, "Renders the flexible configuration template with the settings in FC_SETTINGS, FC_PARTIALS and FC_TEMPLATES, reporting the template errors and the undefined settings")
+	checkQuietFlag := BoolFlagBuilder(&checkQuiet, "quiet", "q", checkQuiet, "Prints only the failures, so nothing is printed when the check succeeds")
+	checkVerboseFlag := CountFlagBuilder(&checkVerbose, "verbose", "v", "Prints diagnostic messages about the check itself, like the schema resolution and timings. Repeat it for more detail")
+	checkDumpFormatFlag := StringFlagBuilder(&checkDumpFormat, "dump-format", "", checkDumpFormat, "Format of the dump of the parsed configuration: text or json. The json dump contains the resolved configuration, it is written to stdout and does not require --debug")
+	checkProbeBackendsFlag := BoolFlagBuilder(&checkProbeBackends, "probe-backends", "", checkProbeBackends, "Sends a HEAD request to every static backend host, reporting the unreachable ones. They fail the check only with --warn-as-error")
+	checkProbeTimeoutFlag := DurationFlagBuilder(&checkProbeTimeout, "probe-timeout", "", checkProbeTimeout, "Timeout of every backend probe (e.g. 3s, 500ms)")
+	checkProbeConcurrencyFlag := IntFlagBuilder(&checkProbeConcurrency, "probe-concurrency", "", checkProbeConcurrency, "Maximum number of backend probes in flight")
+	checkRawFlag := BoolFlagBuilder(&checkRaw, "raw", "", checkRaw, "Lints the configuration as written, without parsing it, so the flexible configuration is not rendered. The dump and the routes testing are skipped")
+	checkDumpOnlyFlag := BoolFlagBuilder(&checkDumpOnly, "dump-only", "", checkDumpOnly, "Parses and dumps the configuration, skipping the linting and the routes testing")
+	runTimeoutFlag := DurationFlagBuilder(&runTimeout, "run-timeout", "", runTimeout, "Time the gin router has to start when testing the routes (e.g. 5s)")
+	checkPortFlag := IntFlagBuilder(&checkPort, "port", "p", checkPort, "Port of the router testing the routes. Use 0 for a free port chosen by the OS, so it does not conflict with a running instance. The port of the configuration is used when negative")
+	checkBuildOnlyFlag := BoolFlagBuilder(&checkBuildOnly, "build-only", "", checkBuildOnly, "Tests the routes registering them in the gin router without listening on any port")
+	checkFailFastFlag := BoolFlagBuilder(&checkFailFast, "fail-fast", "", checkFailFast, "Stops checking a file at its first failure. With --fail-fast=false all the checks run and their failures are reported together. The rest of the files are checked anyway")
+	checkJobsFlag := IntFlagBuilder(&checkJobs, "jobs", "j", checkJobs, "Number of configuration files checked at the same time. The files testing the routes are checked one at a time")
+	checkExitZeroFlag := BoolFlagBuilder(&checkExitZero, "exit-zero", "", checkExitZero, "Exits with code 0 even when the check fails, still reporting the failures. The wrong usages keep failing")
+	checkSummaryFlag := BoolFlagBuilder(&checkSummary, "summary", "", checkSummary, "Prints the number of endpoints, backends, async agents and plugins of the valid configuration, or adds them to the result with --format json. It is enabled by --verbose too")
+	checkTimingsFlag := BoolFlagBuilder(&checkTimings, "timings", "", checkTimings, "Prints the duration of every phase of the check, or adds them to the result with --format json")
+	schemaBaseURLFlag := StringFlagBuilder(&schemaBaseURL, "schema-base-url", "", schemaBaseURL, "Location of the official online schema, like an internal mirror. Use a %s placeholder for the version, or the /vMAJOR.MINOR/krakend.json path is appended. It defaults to the KRAKEND_SCHEMA_BASE_URL env var")
+	schemaBaseURIFlag := StringFlagBuilder(&schemaBaseURI, "schema-base-uri", "", schemaBaseURI, "Base URI or directory used to resolve the relative $ref of the custom schemas. The location of every schema is used by default")
+	checkReportFileFlag := StringFlagBuilder(&checkReportFile, "report-file", "", checkReportFile, "Writes the json, sarif or junit result to the file instead of stdout, printing the text messages as well")
+	checkOverridesFlag := StringArrayFlagBuilder(&checkOverrides, "set", "", nil, "Overrides a value of the parsed configuration before checking it, as path=value with a dot separated path like endpoints.0.timeout=2s. The value is decoded as JSON or used as a string. It can be repeated")
+	checkOverridesCreateFlag := BoolFlagBuilder(&checkOverridesCreate, "set-create", "", checkOverridesCreate, "Adds the paths of the overrides missing in the configuration, instead of failing")
+	checkOutputConfigFlag := StringFlagBuilder(&checkOutputConfig, "output-config", "", checkOutputConfig, "Writes the resolved configuration as JSON to the file when the check passes, so it can be deployed as a static configuration")
+	lintTolerantJSONFlag := BoolFlagBuilder(&lintTolerantJSON, "tolerant-json", "", lintTolerantJSON, "Removes the comments and the trailing commas of the JSON configuration before linting it, warning when the content is altered")
+	checkMaxConfigSizeFlag := Int64FlagBuilder(&checkMaxConfigSize, "max-config-size", "", checkMaxConfigSize, "Maximum size in bytes of the configuration, as read and as resolved by the parser. 0 disables the limit")
+	checkChangedFlag := BoolFlagBuilder(&checkChanged, "changed", "", checkChanged, "Checks only the configuration files changed in the git repository since the merge base with --base, including the uncommitted changes. The deleted files are skipped")
+	checkChangedBaseFlag := StringFlagBuilder(&checkChangedBase, "base", "", checkChangedBase, "Git ref the changed files are compared against with --changed")
+	checkTraceIncludesFlag := BoolFlagBuilder(&checkTraceIncludes, "trace-includes", "", checkTraceIncludes, "Prints the tree of the settings, templates and partials included by the flexible configuration, with their absolute paths")
+	checkIncludeRootFlag := StringFlagBuilder(&checkIncludeRoot, "include-root", "", checkIncludeRoot, "Fails the check when the configuration, the flexible configuration dirs or an included partial resolve outside this directory, without parsing it. It implies --template-check. It is only enforced by the check, not by the parser used by run")
+	checkListRoutesFlag := BoolFlagBuilder(&checkListRoutes, "list-routes", "", checkListRoutes, "Tests the routes like --test-gin-routes and prints the registered ones with their backend hosts")
+	checkPrintSourceFlag := BoolFlagBuilder(&checkPrintSource, "print-source", "", checkPrintSource, "Writes the source assembled by the parser (e.g. the rendered flexible configuration) to stdout and exits")
+	checkOutputFormatFlag := StringFlagBuilder(&checkOutputFormat, "format", "o", checkOutputFormat, "Output format of the check result: text, json, sarif or junit")
+	checkConfigFormatFlag := StringFlagBuilder(&checkConfigFormat, "config-format", "", checkConfigFormat, "Format of the configuration: json, yaml or toml. It is detected by the extension of the file or by its content by default")
+	checkConfigInlineFlag := StringFlagBuilder(&checkConfigInline, "config-inline", "", checkConfigInline, "Configuration to check, passed as a JSON string instead of a file")
+	checkCfgFlag := StringArrayFlagBuilder(&checkConfigFiles, "config", "c", nil, "Path or glob pattern of the configuration file(s), http(s) URL to download it from, or - to read it from stdin. It can be repeated")
+	CheckCommand = NewCommand(checkCmd, checkCfgFlag, checkDebugFlag, ginRoutesFlag, prefixFlag, lintCurrentSchemaFlag, lintCustomSchemaFlag, lintNoNetworkFlag, checkOutputFormatFlag, schemaCacheTTLFlag, schemaNoCacheFlag, schemaVersionFlag, schemaProxyFlag, schemaTimeoutFlag, schemaRetriesFlag, schemaHeaderFlag, lintStrictFlag, checkQuietFlag, checkVerboseFlag, checkDumpFormatFlag, checkDumpOnlyFlag, runTimeoutFlag, checkListRoutesFlag, lintWarnAsErrorFlag, lintIgnoreFlag, checkEnvFlag, checkTemplateFlag, checkPrintSourceFlag, checkPortFlag, checkBuildOnlyFlag, checkFailFastFlag, checkTimingsFlag, schemaBaseURIFlag, checkReportFileFlag, checkIncludeRootFlag, schemaBaseURLFlag, lintFragmentFlag, lintMaxErrorsFlag, dumpPrefixFlag, checkConfigInlineFlag, lintExplainFlag, checkDeprecationsFlag, checkTargetVersionFlag, checkWatchFlag, checkRawFlag, checkProbeBackendsFlag, checkProbeTimeoutFlag, checkProbeConcurrencyFlag, checkNamespacesFlag, checkConfigFormatFlag, lintDisabledFlag, checkSummaryFlag, checkExitZeroFlag, checkJobsFlag, checkTraceIncludesFlag, lintNoAutoSchemaFlag, checkOverridesFlag, checkOverridesCreateFlag, checkChangedFlag, checkChangedBaseFlag, checkMaxConfigSizeFlag, checkOutputConfigFlag, lintTolerantJSONFlag)
+	CheckCommand.AddConstraint(MutuallyExclusive("lint", "lint-no-network"))
+	CheckCommand.AddConstraint(MutuallyExclusive("lint-no-network", "schema-version"))
+	CheckCommand.AddConstraint(MutuallyExclusive("quiet", "verbose"))
+	CheckCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
+	CheckCommand.AddConstraint(FlagCompletion("schema-version", completeSchemaVersions))
+	CheckCommand.AddConstraint(FlagCompletion("target-version", completeSchemaVersions))
+	CheckCommand.AddConstraint(FlagCompletion("format", completeValues(checkFormats...)))
+	CheckCommand.AddConstraint(FlagCompletion("dump-format", completeValues(formatText, formatJSON)))
+	CheckCommand.AddConstraint(FlagCompletion("config-format", completeValues(configFormats...)))
+	CheckCommand.AddConstraint(MutuallyExclusive("config", "config-inline"))
+	CheckCommand.AddConstraint(MutuallyExclusive("indent", "dump-prefix"))
+	CheckCommand.AddConstraint(Deprecated("indent", "use --dump-prefix instead"))
+	CheckCommand.AddConstraint(MutuallyExclusive("raw", "dump-only"))
+	CheckCommand.AddConstraint(MutuallyExclusive("raw", "print-source"))
+	CheckCommand.AddConstraint(MutuallyExclusive("raw", "no-lint"))
+	CheckCommand.AddConstraint(MutuallyExclusive("raw", "set"))
+	CheckCommand.AddConstraint(MutuallyExclusive("changed", "config-inline"))
+	CheckCommand.AddConstraint(MutuallyExclusive("changed", "watch"))
 
 	portFlag := IntFlagBuilder(&port, "port", "p", 0, "Listening port for the http service")
 	RunCommand = NewCommand(runCmd, cfgFlag, debugFlag, portFlag)
+	RunCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
 
 	goSumFlag := StringFlagBuilder(&goSum, "sum", "s", goSum, "Path to the go.sum file to analyze")
 	goVersionFlag := StringFlagBuilder(&goVersion, "go", "g", goVersion, "The version of the go compiler used for your plugin")
@@ -124,11 +387,66 @@ func init() {
 	severitiesToIncludeFlag := StringFlagBuilder(&severitiesToInclude, "severity", "s", severitiesToInclude, "List of severities to include (comma-separated, no spaces)")
 	pathToRulesToExcludeFlag := StringFlagBuilder(&rulesToExcludePath, "ignore-file", "I", rulesToExcludePath, "Path to a text-plain file containing the list of rules to exclude")
 	formatFlag := StringFlagBuilder(&formatTmpl, "format", "f", formatTmpl, "Inline go template to render the results")
-	AuditCommand = NewCommand(auditCmd, cfgFlag, rulesToExcludeFlag, severitiesToIncludeFlag, pathToRulesToExcludeFlag, formatFlag)
+	auditOutputFlag := StringFlagBuilder(&auditOutput, "output", "o", auditOutput, "Output format of the results: text, rendered with the --format template, or json, encoded as a JSON document in stdout")
+	auditEnableFlag := StringFlagBuilder(&auditEnabledRules, "audit-enable", "", auditEnabledRules, "List of the only rules to report (comma-separated). All the rules are reported by default")
+	auditDisableFlag := StringFlagBuilder(&auditDisabledRules, "audit-disable", "", auditDisabledRules, "List of rules to disable (comma-separated), added to the ignored ones")
+	auditSeverityFlag := StringFlagBuilder(&auditSeverityGate, "audit-severity", "", auditSeverityGate, "Minimum severity of the recommendations failing the audit: LOW, MEDIUM, HIGH or CRITICAL. Any recommendation fails it by default")
+	auditListRulesFlag := BoolFlagBuilder(&auditListRules, "list-rules", "", auditListRules, "Lists the available rules and exits")
+	AuditCommand = NewCommand(auditCmd, cfgFlag, rulesToExcludeFlag, severitiesToIncludeFlag, pathToRulesToExcludeFlag, formatFlag, auditOutputFlag, auditEnableFlag, auditDisableFlag, auditSeverityFlag, auditListRulesFlag)
+	AuditCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
+
+	fmtStdoutFlag := BoolFlagBuilder(&fmtStdout, "stdout", "", fmtStdout, "Writes the formatted content to stdout instead of rewriting the file")
+	fmtCheckFlag := BoolFlagBuilder(&fmtCheck, "check", "", fmtCheck, "Lists the files not formatted and exits with an error if there is any, without rewriting them")
+	FmtCommand = NewCommand(fmtCmd, checkCfgFlag, fmtStdoutFlag, fmtCheckFlag)
+	FmtCommand.AddConstraint(MutuallyExclusive("stdout", "check"))
+	FmtCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
+
+	diffConfigBFlag := StringFlagBuilder(&diffConfigB, "config-b", "b", diffConfigB, "Path to the configuration file to compare with")
+	diffFormatFlag := StringFlagBuilder(&diffFormat, "format", "o", diffFormat, "Output format of the differences: text or json")
+	DiffCommand = NewCommand(diffCmd, cfgFlag, diffConfigBFlag, diffFormatFlag)
+	DiffCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
+	DiffCommand.AddConstraint(FlagCompletion("config-b", completeConfigFiles))
+	DiffCommand.AddConstraint(FlagCompletion("format", completeValues(formatText, formatJSON)))
 
 	VersionCommand = NewCommand(versionCmd)
 
-	DefaultRoot = NewRoot(RootCommand, CheckCommand, RunCommand, PluginCommand, VersionCommand, AuditCommand)
+	schemaFetchVersionFlag := StringFlagBuilder(&schemaVersion, "version", "", schemaVersion, "Version (MAJOR.MINOR) of the schema to download. The version of this binary is used by default")
+	schemaOutFlag := StringFlagBuilder(&schemaOut, "out", "", schemaOut, "Path of the file to save the schema to. The schema is written to stdout when empty")
+	SchemaCommand = NewCommand(schemaCmd)
+	schemaIndentFlag := StringFlagBuilder(&schemaIndent, "indent", "i", schemaIndent, "Indentation of the printed schema")
+	SchemaCommand.AddChild(NewCommand(schemaPrintCmd, schemaIndentFlag))
+	schemaFetchCommand := NewCommand(schemaFetchCmd, schemaFetchVersionFlag, schemaOutFlag, schemaCacheTTLFlag, schemaNoCacheFlag, schemaProxyFlag, schemaTimeoutFlag, schemaRetriesFlag, schemaHeaderFlag, schemaBaseURLFlag)
+	schemaFetchCommand.AddConstraint(FlagCompletion("version", completeSchemaVersions))
+	SchemaCommand.AddChild(schemaFetchCommand)
+	schemaDiffVersionFlag := StringFlagBuilder(&schemaVersion, "version", "", schemaVersion, "Version (MAJOR.MINOR) of the online schema to compare. The version of this binary is used by default")
+	schemaDiffFormatFlag := StringFlagBuilder(&schemaDiffFormat, "format", "o", schemaDiffFormat, "Output format of the differences: text or json")
+	schemaDiffCommand := NewCommand(schemaDiffCmd, schemaDiffVersionFlag, schemaDiffFormatFlag, schemaCacheTTLFlag, schemaNoCacheFlag, schemaProxyFlag, schemaTimeoutFlag, schemaRetriesFlag, schemaHeaderFlag, schemaBaseURLFlag)
+	schemaDiffCommand.AddConstraint(FlagCompletion("version", completeSchemaVersions))
+	schemaDiffCommand.AddConstraint(FlagCompletion("format", completeValues(formatText, formatJSON)))
+	SchemaCommand.AddChild(schemaDiffCommand)
+	schemaUnlinkFlag := BoolFlagBuilder(&schemaUnlink, "remove", "", schemaUnlink, "Removes the $schema property instead of setting it")
+	schemaLinkVersionFlag := StringFlagBuilder(&schemaVersion, "version", "", schemaVersion, "Version (MAJOR.MINOR) of the schema to link. The version of this binary is used by default")
+	schemaLinkCommand := NewCommand(schemaLinkCmd, cfgFlag, schemaLinkVersionFlag, schemaUnlinkFlag, schemaBaseURLFlag)
+	schemaLinkCommand.AddConstraint(FlagCompletion("config", completeConfigFiles))
+	schemaLinkCommand.AddConstraint(FlagCompletion("version", completeSchemaVersions))
+	SchemaCommand.AddChild(schemaLinkCommand)
+
+	initOutFlag := StringFlagBuilder(&initOut, "out", "o", initOut, "Path of the configuration file to create")
+	initForceFlag := BoolFlagBuilder(&initForce, "force", "f", initForce, "Overwrites the file if it already exists")
+	initLinkSchemaFlag := BoolFlagBuilder(&initLinkSchema, "link-schema", "", initLinkSchema, "Sets the $schema property to the official JSON schema of the version of this binary")
+	initSchemaVersionFlag := StringFlagBuilder(&schemaVersion, "version", "", schemaVersion, "Version (MAJOR.MINOR) of the schema to link. The version of this binary is used by default")
+	initTemplateFlag := StringFlagBuilder(&initTemplateName, "template", "t", initTemplateName, "Starter configuration to generate. See them with --list-templates")
+	initListTemplatesFlag := BoolFlagBuilder(&initListTemplates, "list-templates", "", initListTemplates, "Lists the available templates and exits")
+	InitCommand = NewCommand(initCmd, initOutFlag, initForceFlag, initLinkSchemaFlag, initSchemaVersionFlag, schemaBaseURLFlag, initTemplateFlag, initListTemplatesFlag)
+	InitCommand.AddConstraint(FlagCompletion("template", completeValues(initTemplateNames()...)))
+	InitCommand.AddConstraint(FlagCompletion("version", completeSchemaVersions))
+
+	lintRulesFormatFlag := StringFlagBuilder(&lintRulesFormat, "format", "f", lintRulesFormat, "Output format of the rules: text or json")
+	LintRulesCommand = NewCommand(lintRulesCmd, lintCurrentSchemaFlag, lintNoNetworkFlag, lintCustomSchemaFlag, schemaVersionFlag, schemaBaseURIFlag, schemaBaseURLFlag, schemaCacheTTLFlag, schemaNoCacheFlag, schemaProxyFlag, schemaTimeoutFlag, schemaRetriesFlag, schemaHeaderFlag, lintRulesFormatFlag)
+	LintRulesCommand.AddConstraint(FlagCompletion("format", completeValues(formatText, formatJSON)))
+	LintRulesCommand.AddConstraint(FlagCompletion("schema-version", completeSchemaVersions))
+
+	DefaultRoot = NewRoot(RootCommand, CheckCommand, RunCommand, PluginCommand, VersionCommand, AuditCommand, FmtCommand, DiffCommand, SchemaCommand, InitCommand, LintRulesCommand)
 }
 
 const encodedLogo = "IOKVk+KWhOKWiCAgICAgICAgICAgICAgICAgICAgICAgICAg4paE4paE4paMICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgIOKVk+KWiOKWiOKWiOKWiOKWiOKWiOKWhMK1ICAK4paQ4paI4paI4paIICDiloTilojilojilojilajilpDilojilojilojiloTilojilohI4pWX4paI4paI4paI4paI4paI4paI4paEICDilZHilojilojilowgLOKWhOKWiOKWiOKWiOKVqCDiloTilojilojilojilojilojilojiloQgIOKWk+KWiOKWiOKWjOKWiOKWiOKWiOKWiOKWiOKWhCAg4paI4paI4paI4paA4pWZ4pWZ4paA4paA4paI4paI4paI4pWVCuKWkOKWiOKWiOKWiOKWhOKWiOKWiOKWiOKWgCAg4paQ4paI4paI4paI4paI4paI4paAIuKVmeKWgOKWgCLilZniloDilojilojilogg4pWR4paI4paI4paI4paE4paI4paI4paI4pSYICDilojilojilojiloAiIuKWgOKWiOKWiOKWiCDilojilojilojilojiloDilZniloDilojilojilohIIOKWiOKWiOKWiCAgICAg4pWZ4paI4paI4paICuKWkOKWiOKWiOKWiOKWiOKWiOKWiOKWjCAgIOKWkOKWiOKWiOKWiOKMkCAgLOKWhOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiE3ilZHilojilojilojilojilojilojiloQgIOKVkeKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiE3ilojilojilojilowgICDilojilojilohIIOKWiOKWiOKWiCAgICAgLOKWiOKWiOKWiArilpDilojilojilojilajiloDilojilojilojCtSDilpDilojilojiloggICDilojilojilojilowgICzilojilojilohN4pWR4paI4paI4paI4pWZ4paA4paI4paI4paIICDilojilojilojiloRgYGDiloTiloRgIOKWiOKWiOKWiOKWjCAgIOKWiOKWiOKWiEgg4paI4paI4paILCws4pWT4paE4paI4paI4paI4paACuKWkOKWiOKWiOKWiCAg4pWZ4paI4paI4paI4paE4paQ4paI4paI4paIICAg4pWZ4paI4paI4paI4paI4paI4paI4paI4paI4paITeKVkeKWiOKWiOKWjCAg4pWZ4paI4paI4paI4paEYOKWgOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKVqCDilojilojilojilowgICDilojilojilohIIOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWiOKWgCAgCiAgICAgICAgICAgICAgICAgICAgIGBgICAgICAgICAgICAgICAgICAgICAgYCdgICAgICAgICAgICAgICAgICAgICAgICAgICAgIAo="
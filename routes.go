@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/router"
+	krakendgin "github.com/luraproject/lura/v2/router/gin"
+)
+
+// RouteInfo is a route registered by the gin router, with the hosts of the backends of its
+// endpoint. Routes added by the router itself, like the debug ones, have no backends
+type RouteInfo struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Backends []string `json:"backends,omitempty"`
+	// InputHeaders are the headers of the request passed to the backends
+	InputHeaders   []string `json:"input_headers,omitempty"`
+	OutputEncoding string   `json:"output_encoding,omitempty"`
+}
+
+// ginAnyMethods are the methods of the routes the gin router registers for any method
+var ginAnyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodHead,
+	http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+// ListRoutesFunc runs the gin router like RunRouterFunc and returns the registered routes,
+// sorted by path and method
+var ListRoutesFunc = func(cfg config.ServiceConfig) ([]RouteInfo, error) {
+	registered, err := runRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := map[string]RouteInfo{}
+	for _, r := range RouteTable(cfg) {
+		declared[r.Method+" "+r.Path] = r
+	}
+	routes := make([]RouteInfo, len(registered))
+	for i, r := range registered {
+		routes[i] = declared[r.Method+" "+r.Path]
+		routes[i].Method, routes[i].Path = r.Method, r.Path
+	}
+	sortRoutes(routes)
+	return routes, nil
+}
+
+// RouteTable returns the routes the gin router registers for the configuration, sorted by path
+// and method, without running it. Like the router, it skips the endpoints with an unsupported
+// method and adds the debug, echo and automatic OPTIONS routes when enabled. The endpoints the
+// router discards because their proxy can not be built are listed anyway. The configuration
+// must be initialized, as the parser does
+func RouteTable(cfg config.ServiceConfig) []RouteInfo {
+	routes := []RouteInfo{}
+	for _, enabled := range []struct {
+		on   bool
+		path string
+	}{{cfg.Debug, "/__debug/*param"}, {cfg.Echo, "/__echo/*param"}} {
+		if !enabled.on {
+			continue
+		}
+		for _, m := range ginAnyMethods {
+			routes = append(routes, RouteInfo{Method: m, Path: enabled.path})
+		}
+	}
+
+	methods := map[string][]string{}
+	for _, e := range cfg.Endpoints {
+		method := strings.ToTitle(e.Method)
+		switch method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			continue
+		}
+		if method != http.MethodGet && len(e.Backend) > 1 && !router.IsValidSequentialEndpoint(e) {
+			continue
+		}
+
+		var hosts []string
+		for _, b := range e.Backend {
+			hosts = append(hosts, b.Host...)
+		}
+		routes = append(routes, RouteInfo{
+			Method:         method,
+			Path:           e.Endpoint,
+			Backends:       hosts,
+			InputHeaders:   e.HeadersToPass,
+			OutputEncoding: e.OutputEncoding,
+		})
+		methods[e.Endpoint] = append(methods[e.Endpoint], method)
+	}
+
+	if opts, ok := cfg.ExtraConfig[krakendgin.Namespace].(map[string]interface{}); ok {
+		if v, ok := opts["auto_options"].(bool); ok && v {
+			for path := range methods {
+				routes = append(routes, RouteInfo{Method: http.MethodOptions, Path: path})
+			}
+		}
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+func sortRoutes(routes []RouteInfo) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+}
+
+// endpointCollision is a pair of endpoints registering the same route
+type endpointCollision struct {
+	First, Second int
+	Method        string
+	Paths         [2]string
+}
+
+func (c endpointCollision) String() string {
+	return fmt.Sprintf("endpoints %d and %d collide: %s %s and %s %s", c.First, c.Second, c.Method, c.Paths[0], c.Method, c.Paths[1])
+}
+
+// endpointCollisions detects the endpoints registering the same method and path. The paths
+// differing only in the name of their parameters collide, as the router can not tell them apart
+func endpointCollisions(endpoints []*config.EndpointConfig) []endpointCollision {
+	var collisions []endpointCollision
+	seen := map[string]int{}
+	for i, e := range endpoints {
+		method := strings.ToUpper(e.Method)
+		key := method + " " + routePattern(e.Endpoint)
+		if first, ok := seen[key]; ok {
+			collisions = append(collisions, endpointCollision{
+				First:  first,
+				Second: i,
+				Method: method,
+				Paths:  [2]string{endpoints[first].Endpoint, e.Endpoint},
+			})
+			continue
+		}
+		seen[key] = i
+	}
+	return collisions
+}
+
+// routePattern removes the names of the parameters and wildcards of the path
+func routePattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"), strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+			segments[i] = ":"
+		case strings.HasPrefix(s, "*"):
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	krakendgin "github.com/luraproject/lura/v2/router/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_endpointCollisions(t *testing.T) {
+	endpoints := []*config.EndpointConfig{
+		{Endpoint: "/foo/:id", Method: "GET"},
+		{Endpoint: "/foo/:id", Method: "POST"},
+		{Endpoint: "/foo/bar", Method: "GET"},
+		{Endpoint: "/foo/:name", Method: "GET"},
+		{Endpoint: "/static/*path", Method: "GET"},
+		{Endpoint: "/static/*file", Method: "get"},
+	}
+
+	collisions := endpointCollisions(endpoints)
+	require.Equal(t, []endpointCollision{
+		{First: 0, Second: 3, Method: "GET", Paths: [2]string{"/foo/:id", "/foo/:name"}},
+		{First: 4, Second: 5, Method: "GET", Paths: [2]string{"/static/*path", "/static/*file"}},
+	}, collisions)
+	require.Equal(t, "endpoints 0 and 3 collide: GET /foo/:id and GET /foo/:name", collisions[0].String())
+}
+
+func Test_runRouter_buildOnly(t *testing.T) {
+	origBuildOnly, origTimeout := checkBuildOnly, runTimeout
+	defer func() { checkBuildOnly, runTimeout = origBuildOnly, origTimeout }()
+	checkBuildOnly = true
+	// a listening router would block until the timeout
+	runTimeout = time.Minute
+
+	cfg := config.ServiceConfig{
+		Version: 3,
+		Endpoints: []*config.EndpointConfig{{
+			Endpoint: "/foo",
+			Method:   "GET",
+			Backend:  []*config.Backend{{URLPattern: "/bar", Host: []string{"http://127.0.0.1:8080"}}},
+		}},
+	}
+	require.NoError(t, cfg.Init())
+	cfg.Port, _ = freePort()
+
+	start := time.Now()
+	routes, err := runRouter(cfg)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.Len(t, routes, 1)
+	require.Equal(t, "/foo", routes[0].Path)
+}
+
+func Test_runRouter_runTimeout(t *testing.T) {
+	origBuildOnly, origTimeout := checkBuildOnly, runTimeout
+	defer func() { checkBuildOnly, runTimeout = origBuildOnly, origTimeout }()
+	checkBuildOnly = false
+	runTimeout = 300 * time.Millisecond
+
+	cfg := config.ServiceConfig{Version: 3}
+	require.NoError(t, cfg.Init())
+	cfg.Port, _ = freePort()
+
+	start := time.Now()
+	_, err := runRouter(cfg)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), runTimeout)
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRouteTable(t *testing.T) {
+	origBuildOnly := checkBuildOnly
+	defer func() { checkBuildOnly = origBuildOnly }()
+	checkBuildOnly = true
+
+	backend := func(host string) []*config.Backend {
+		return []*config.Backend{{URLPattern: "/", Host: []string{host}}}
+	}
+	cfg := config.ServiceConfig{
+		Version: 3,
+		Debug:   true,
+		ExtraConfig: config.ExtraConfig{
+			krakendgin.Namespace: map[string]interface{}{"auto_options": true},
+		},
+		Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/users/{id}", Method: "GET", Backend: backend("http://a"), HeadersToPass: []string{"x-user"}},
+			{Endpoint: "/users/{id}", Method: "delete", Backend: backend("http://b"), OutputEncoding: "no-op"},
+			{Endpoint: "/search", Method: "GET", Backend: append(backend("http://a"), backend("http://c")...)},
+			{Endpoint: "/ignored", Method: "CONNECT", Backend: backend("http://a")},
+		},
+	}
+	require.NoError(t, cfg.Init())
+	cfg.Port, _ = freePort()
+
+	routes := RouteTable(cfg)
+	require.Contains(t, routes, RouteInfo{Method: "GET", Path: "/users/:id", Backends: []string{"http://a"}, InputHeaders: []string{"X-User"}, OutputEncoding: "json"})
+	require.Contains(t, routes, RouteInfo{Method: "DELETE", Path: "/users/:id", Backends: []string{"http://b"}, OutputEncoding: "no-op"})
+	require.Contains(t, routes, RouteInfo{Method: "OPTIONS", Path: "/users/:id"})
+	require.Contains(t, routes, RouteInfo{Method: "TRACE", Path: "/__debug/*param"})
+	for _, r := range routes {
+		require.NotEqual(t, "/ignored", r.Path)
+	}
+
+	// the same routes the router registers
+	registered, err := ListRoutesFunc(cfg)
+	require.NoError(t, err)
+	require.Equal(t, registered, routes)
+}
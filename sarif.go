@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/luraproject/lura/v2/core"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifRuleID identifies the kind of the error: the failing keyword for the lint errors
+// and the stage for the rest
+func sarifRuleID(e CheckError) string {
+	if e.Stage == stageLint && e.Keyword != "" {
+		return "lint/" + e.Keyword
+	}
+	return e.Stage
+}
+
+func newSARIFLog(results []CheckResult) sarifLog {
+	rules := map[string]struct{}{}
+	sarifResults := []sarifResult{}
+
+	for _, res := range results {
+		for _, e := range res.Errors {
+			id := sarifRuleID(e)
+			rules[id] = struct{}{}
+			sarifResults = append(sarifResults, newSARIFResult(res.ConfigFile, id, "error", e))
+		}
+		for _, e := range res.Warnings {
+			id := sarifRuleID(e)
+			rules[id] = struct{}{}
+			sarifResults = append(sarifResults, newSARIFResult(res.ConfigFile, id, "warning", e))
+		}
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	sarifRules := make([]sarifRule, len(ids))
+	for i, id := range ids {
+		sarifRules[i] = sarifRule{ID: id, ShortDescription: sarifMessage{Text: "KrakenD configuration check: " + id}}
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "krakend",
+				Version:        core.KrakendVersion,
+				InformationURI: "https://www.krakend.io",
+				Rules:          sarifRules,
+			}},
+			Results: sarifResults,
+		}},
+	}
+}
+
+func newSARIFResult(configFile, id, level string, e CheckError) sarifResult {
+	sr := sarifResult{
+		RuleID:  id,
+		Level:   level,
+		Message: sarifMessage{Text: e.Message},
+	}
+	if e.Location != "" {
+		sr.Message.Text = e.Location + ": " + e.Message
+	}
+
+	loc := sarifLocation{}
+	if configFile != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(configFile)},
+		}
+		if e.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: e.Line, StartColumn: e.Column}
+		}
+	}
+	if e.Location != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: e.Location}}
+	}
+	if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+		sr.Locations = []sarifLocation{loc}
+	}
+	return sr
+}
+
+func writeSARIF(w io.Writer, results []CheckResult) error {
+	enc := newJSONEncoder(w)
+	return enc.Encode(newSARIFLog(results))
+}
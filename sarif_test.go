@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sarifRuleID(t *testing.T) {
+	require.Equal(t, "lint/required", sarifRuleID(CheckError{Stage: stageLint, Keyword: "required"}))
+	require.Equal(t, stageLint, sarifRuleID(CheckError{Stage: stageLint}))
+	require.Equal(t, stageRoutes, sarifRuleID(CheckError{Stage: stageRoutes, Keyword: "required"}))
+}
+
+func Test_newSARIFLog(t *testing.T) {
+	results := []CheckResult{
+		{ConfigFile: "ok.json", Errors: []CheckError{}},
+		{
+			ConfigFile: "conf/bad.json",
+			Errors: []CheckError{
+				{Stage: stageRoutes, Message: "duplicated route"},
+				{Stage: stageLint, Keyword: "const", Message: "value must be 3", Location: "/version", Line: 2, Column: 14},
+			},
+			Warnings: []CheckError{{Stage: stageLint, Keyword: "deprecated", Message: "property 'cache_ttl' is deprecated", Location: "/cache_ttl"}},
+		},
+	}
+
+	log := newSARIFLog(results)
+	require.Equal(t, sarifSchema, log.Schema)
+	require.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	require.Equal(t, []sarifRule{
+		{ID: "lint/const", ShortDescription: sarifMessage{Text: "KrakenD configuration check: lint/const"}},
+		{ID: "lint/deprecated", ShortDescription: sarifMessage{Text: "KrakenD configuration check: lint/deprecated"}},
+		{ID: stageRoutes, ShortDescription: sarifMessage{Text: "KrakenD configuration check: routes"}},
+	}, run.Tool.Driver.Rules)
+
+	artifact := sarifArtifactLocation{URI: "conf/bad.json"}
+	require.Equal(t, []sarifResult{
+		{
+			RuleID:    stageRoutes,
+			Level:     "error",
+			Message:   sarifMessage{Text: "duplicated route"},
+			Locations: []sarifLocation{{PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: artifact}}},
+		},
+		{
+			RuleID:  "lint/const",
+			Level:   "error",
+			Message: sarifMessage{Text: "/version: value must be 3"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: artifact, Region: &sarifRegion{StartLine: 2, StartColumn: 14}},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/version"}},
+			}},
+		},
+		{
+			RuleID:  "lint/deprecated",
+			Level:   "warning",
+			Message: sarifMessage{Text: "/cache_ttl: property 'cache_ttl' is deprecated"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: artifact},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/cache_ttl"}},
+			}},
+		},
+	}, run.Results)
+
+	require.Empty(t, newSARIFResult("", stageRoutes, "error", CheckError{Message: "duplicated route"}).Locations)
+}
+
+func Test_writeSARIF(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeSARIF(&out, []CheckResult{{ConfigFile: "ok.json", Errors: []CheckError{}}}))
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Equal(t, sarifSchema, got["$schema"])
+	runs := got["runs"].([]interface{})
+	require.Len(t, runs, 1)
+	require.Equal(t, []interface{}{}, runs[0].(map[string]interface{})["results"])
+}
@@ -0,0 +1,487 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// SchemaLoaderOptions defines how the remote schemas are downloaded and cached
+type SchemaLoaderOptions struct {
+	// Timeout bounds the whole download of a document, including the retries
+	Timeout      time.Duration
+	Retries      int
+	RetryBackoff time.Duration
+	// Proxy takes precedence over the HTTP_PROXY, HTTPS_PROXY and NO_PROXY env vars
+	Proxy string
+	// Headers added to every request, with the format "Name: Value"
+	Headers  []string
+	CacheTTL time.Duration
+	// NoCache forces a fresh download of the documents
+	NoCache bool
+	// Logf, when set, receives the diagnostic messages of the loader
+	Logf func(format string, a ...interface{})
+	// Progressf, when set, periodically receives a message while a download is in flight
+	Progressf func(format string, a ...interface{})
+	// Context, when set, cancels the downloads in flight once it is done
+	Context context.Context
+}
+
+func (o SchemaLoaderOptions) validate() error {
+	if o.Timeout <= 0 {
+		return fmt.Errorf("invalid schema timeout %s. It must be greater than zero", o.Timeout)
+	}
+	if o.Retries < 0 {
+		return fmt.Errorf("invalid number of schema retries %d. It can not be negative", o.Retries)
+	}
+	_, err := parseSchemaHeaders(o.Headers)
+	return err
+}
+
+// newSchemaLoader returns the loader used for resolving the schemas by URL
+func newSchemaLoader(o SchemaLoaderOptions) (jsonschema.SchemeURLLoader, error) {
+	client, err := newSchemaHTTPClient(o)
+	if err != nil {
+		return nil, err
+	}
+	httpLoader := SchemaHttpLoader(*client)
+
+	var remote jsonschema.URLLoader = &httpLoader
+	if o.Progressf != nil {
+		remote = &progressLoader{Loader: remote, Interval: progressInterval(o.Timeout), Timeout: o.Timeout, Progressf: o.Progressf}
+	}
+	if dir, err := schemaCacheDir(); err == nil {
+		remote = &SchemaCacheLoader{Loader: remote, Dir: dir, TTL: o.CacheTTL, Refresh: o.NoCache, Logf: o.Logf}
+	} else if o.Logf != nil {
+		o.Logf("Schema cache disabled: %s\n", err.Error())
+	}
+
+	return jsonschema.SchemeURLLoader{
+		"file":  jsonschema.FileLoader{},
+		"http":  remote,
+		"https": remote,
+	}, nil
+}
+
+// newSchemaHTTPClient returns the client used for downloading remote documents. The declared
+// proxy takes precedence over the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func newSchemaHTTPClient(o SchemaLoaderOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid schema proxy URL %q", o.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	headers, err := parseSchemaHeaders(o.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper = &retryTransport{
+		next:    transport,
+		retries: o.Retries,
+		backoff: o.RetryBackoff,
+	}
+	if len(headers) > 0 {
+		rt = &headerTransport{next: rt, headers: headers}
+	}
+	if o.Context != nil {
+		rt = &contextTransport{next: rt, ctx: o.Context}
+	}
+
+	return &http.Client{
+		Timeout:   o.Timeout,
+		Transport: rt,
+	}, nil
+}
+
+// parseSchemaHeaders parses a list of "Name: Value" definitions. The name and the value are
+// split by the first colon and trimmed
+func parseSchemaHeaders(defs []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, def := range defs {
+		name, value, ok := strings.Cut(def, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("malformed schema header %q. Use the format \"Name: Value\"", def)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// headerTransport adds a fixed set of headers to every request
+type headerTransport struct {
+	next    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// contextTransport cancels the requests when the context is done. The context of the request
+// is kept, as it carries the timeout of the client
+type contextTransport struct {
+	next http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// the body is read after the round trip, so the request is released once it is closed
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// retryTransport retries the idempotent requests failing with a connection error or a
+// retryable status code (429 and 5xx), waiting an exponential backoff between attempts.
+// The client timeout bounds the whole sequence of attempts
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+
+	wait := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.retries || !isRetryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+type SchemaHttpLoader http.Client
+
+// Load downloads and decodes the document. The compressed responses are requested explicitly,
+// so they are decompressed here even when the server sends them unsolicited
+func (l *SchemaHttpLoader) Load(url string) (interface{}, error) {
+	client := (*http.Client)(l)
+	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status code %d", url, resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", url, err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", url, err)
+	}
+	return doc, nil
+}
+
+// progressLoader decorates a loader, reporting every interval that a download is still in flight
+type progressLoader struct {
+	Loader    jsonschema.URLLoader
+	Interval  time.Duration
+	Timeout   time.Duration
+	Progressf func(format string, a ...interface{})
+}
+
+func (l *progressLoader) Load(url string) (interface{}, error) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func(start time.Time) {
+		defer wg.Done()
+		ticker := time.NewTicker(l.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				elapsed := time.Since(start).Round(time.Second)
+				l.Progressf("Still downloading %s (%s elapsed, timeout %s)...\n", url, elapsed, l.Timeout)
+			}
+		}
+	}(time.Now())
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	return l.Loader.Load(url)
+}
+
+// progressInterval reports a slow download ten times before it times out, but not more often
+// than once per second
+func progressInterval(timeout time.Duration) time.Duration {
+	if interval := timeout / 10; interval > time.Second {
+		return interval
+	}
+	return time.Second
+}
+
+// SchemaCacheLoader decorates a loader, persisting the loaded documents in a local directory
+// so they can be reused until they become stale. When Refresh is set, the cached documents
+// are ignored but the fresh ones are still persisted
+type SchemaCacheLoader struct {
+	Loader  jsonschema.URLLoader
+	Dir     string
+	TTL     time.Duration
+	Refresh bool
+	Logf    func(format string, a ...interface{})
+}
+
+func (l *SchemaCacheLoader) logf(format string, a ...interface{}) {
+	if l.Logf != nil {
+		l.Logf(format, a...)
+	}
+}
+
+func (l *SchemaCacheLoader) Load(url string) (interface{}, error) {
+	path := filepath.Join(l.Dir, schemaCacheKey(url))
+
+	if info, err := os.Stat(path); !l.Refresh && err == nil && time.Since(info.ModTime()) < l.TTL {
+		if f, err := os.Open(path); err == nil {
+			doc, err := jsonschema.UnmarshalJSON(f)
+			_ = f.Close()
+			if err == nil {
+				l.logf("Schema cache hit for %s (%s)\n", url, path)
+				return doc, nil
+			}
+		}
+	}
+
+	l.logf("Schema cache miss for %s, downloading it\n", url)
+	start := time.Now()
+	doc, err := l.Loader.Load(url)
+	if err != nil {
+		return nil, err
+	}
+	l.logf("Schema %s downloaded in %s\n", url, time.Since(start))
+
+	// failing to persist the document is not an error: it is already loaded in memory
+	if err := l.store(path, doc); err != nil {
+		l.logf("Unable to cache the schema %s: %s\n", url, err.Error())
+	}
+
+	return doc, nil
+}
+
+func (l *SchemaCacheLoader) store(path string, doc interface{}) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func schemaCacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// schemaCacheDir returns the directory where the downloaded schemas are stored,
+// following the XDG conventions ($XDG_CACHE_HOME/krakend/schema)
+func schemaCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the cache directory: %w", err)
+	}
+	return filepath.Join(dir, "krakend", "schema"), nil
+}
+
+// customSchemaResource returns the URL a custom schema is compiled with and, when it must be
+// registered explicitly, its document. With a base URI, the schema is registered under it,
+// keeping its file name, so its relative references are resolved against the base
+func customSchemaResource(loader jsonschema.URLLoader, path, baseURI string, stdin io.Reader) (string, interface{}, error) {
+	name := "stdin.json"
+	var doc interface{}
+	var err error
+	switch {
+	case path == stdinConfig:
+		if doc, err = jsonschema.UnmarshalJSON(stdin); err != nil {
+			return "", nil, fmt.Errorf("decoding the schema from stdin: %w", err)
+		}
+		if baseURI == "" {
+			// the relative references are resolved against the working directory
+			return name, doc, nil
+		}
+	case baseURI == "":
+		return path, nil, nil
+	case isSchemaURL(path):
+		if doc, err = loader.Load(path); err != nil {
+			return "", nil, err
+		}
+		u, _ := url.Parse(path)
+		name = pathpkg.Base(u.Path)
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		if doc, err = jsonschema.UnmarshalJSON(f); err != nil {
+			return "", nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		name = filepath.Base(path)
+	}
+
+	resource, err := resolveSchemaURI(baseURI, name)
+	return resource, doc, err
+}
+
+// resolveSchemaURI resolves the name against the base, a URL or a local directory
+func resolveSchemaURI(base, name string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil || !isSchemaURL(base) {
+		abs, err := filepath.Abs(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid schema base URI %q: %w", base, err)
+		}
+		u = &url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.ResolveReference(&url.URL{Path: name}).String(), nil
+}
+
+// isSchemaURL tells if the location is a URL and not a local path. The one letter schemes
+// are considered Windows drives
+func isSchemaURL(location string) bool {
+	u, err := url.Parse(location)
+	return err == nil && len(u.Scheme) > 1
+}
+
+// vendoredSchema is where the teams vendoring the schema keep it, relative to the configuration
+var vendoredSchema = filepath.Join("schema", "krakend.json")
+
+// discoverSchema looks for a schema vendored next to the configuration file: the local file
+// referenced by its $schema property or the vendoredSchema. It returns an empty string when
+// there is none
+func discoverSchema(configFile string, doc interface{}) string {
+	if info, err := os.Stat(configFile); err != nil || !info.Mode().IsRegular() {
+		// the configurations read from stdin, inline or downloaded have no location
+		return ""
+	}
+	dir := filepath.Dir(configFile)
+
+	var candidates []string
+	if m, ok := doc.(map[string]interface{}); ok {
+		if ref := localSchemaReference(m["$schema"]); ref != "" {
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(dir, ref)
+			}
+			candidates = append(candidates, ref)
+		}
+	}
+	for _, c := range append(candidates, filepath.Join(dir, vendoredSchema)) {
+		if info, err := os.Stat(c); err == nil && info.Mode().IsRegular() {
+			return c
+		}
+	}
+	return ""
+}
+
+// localSchemaReference returns the path of the $schema value when it references a local file
+func localSchemaReference(v interface{}) string {
+	ref, _ := v.(string)
+	u, err := url.Parse(ref)
+	switch {
+	case ref == "" || err != nil:
+		return ""
+	case u.Scheme == "file":
+		return u.Path
+	case u.Scheme != "":
+		return ""
+	}
+	return ref
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/cobra"
+)
+
+func schemaFetchFunc(cmd *cobra.Command, args []string) error {
+	return commandExitError(cmd, schemaFetchFuncErr(cmd, args))
+}
+
+func schemaFetchFuncErr(cmd *cobra.Command, _ []string) error {
+	opts, err := schemaLoaderOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	schemaURL, err := onlineSchemaURL(onlineSchemaBaseURL(), schemaVersion)
+	if err != nil {
+		return err
+	}
+	data, err := fetchSchema(schemaURL, opts)
+	if err != nil {
+		return err
+	}
+
+	if schemaOut == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := writeFileAtomic(schemaOut, data); err != nil {
+		return fmt.Errorf("writing the schema: %w", err)
+	}
+	cmd.Println(okMsg(fmt.Sprintf("Schema %s saved to %s", schemaURL, schemaOut)))
+	return nil
+}
+
+// schemaLoaderOptionsFromFlags returns the validated options of the schema loader set by the
+// flags of the schema commands
+func schemaLoaderOptionsFromFlags(cmd *cobra.Command) (SchemaLoaderOptions, error) {
+	opts := SchemaLoaderOptions{
+		Timeout:      schemaTimeout,
+		Retries:      schemaRetries,
+		RetryBackoff: schemaRetryBackoff,
+		Proxy:        schemaProxy,
+		Headers:      schemaHeaders,
+		CacheTTL:     schemaCacheTTL,
+		NoCache:      schemaNoCache,
+		Progressf:    schemaProgressf(cmd, false),
+	}
+	return opts, opts.validate()
+}
+
+// fetchSchema downloads the schema with the loader used for linting and returns it indented,
+// once it is known to compile
+func fetchSchema(schemaURL string, opts SchemaLoaderOptions) ([]byte, error) {
+	doc, _, err := loadSchema(schemaURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(doc, "", fmtIndent)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// loadSchema downloads the schema with the loader used for linting and compiles it
+func loadSchema(schemaURL string, opts SchemaLoaderOptions) (interface{}, *jsonschema.Schema, error) {
+	loader, err := newSchemaLoader(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("preparing the schema loader: %w", err)
+	}
+
+	doc, err := loader.Load(schemaURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("downloading the schema: %w", err)
+	}
+
+	compiler := newSchemaCompiler()
+	compiler.UseLoader(loader)
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid schema %s: %w", schemaURL, err)
+	}
+	sch, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("compiling the schema %s: %w", schemaURL, err)
+	}
+	return doc, sch, nil
+}
+
+func schemaDiffFunc(cmd *cobra.Command, args []string) error {
+	d, err := schemaDiffFuncErr(cmd, args)
+	if err != nil {
+		return commandExitError(cmd, err)
+	}
+	if !d.Empty() {
+		return commandExitError(cmd, &ExitError{Code: ExitCodeFailure})
+	}
+	return nil
+}
+
+func schemaDiffFuncErr(cmd *cobra.Command, _ []string) (ConfigDiff, error) {
+	if schemaDiffFormat != formatText && schemaDiffFormat != formatJSON {
+		return ConfigDiff{}, fmt.Errorf("unknown output format %q. Supported formats: %s, %s", schemaDiffFormat, formatText, formatJSON)
+	}
+	if rawEmbedSchema == "" {
+		return ConfigDiff{}, errors.New("this binary does not embed a schema to compare")
+	}
+	opts, err := schemaLoaderOptionsFromFlags(cmd)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+
+	embedded, err := compileEmbeddedSchema([]byte(rawEmbedSchema))
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	schemaURL, err := onlineSchemaURL(onlineSchemaBaseURL(), schemaVersion)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	_, online, err := loadSchema(schemaURL, opts)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+
+	d := diffSchemaRules(schemaRules([]*jsonschema.Schema{embedded}), schemaRules([]*jsonschema.Schema{online}))
+	if schemaDiffFormat == formatJSON {
+		return d, newJSONEncoder(cmd.OutOrStdout()).Encode(d)
+	}
+	printConfigDiff(cmd, d, UseColors())
+	return d, nil
+}
+
+// diffSchemaRules compares the constraints the schemas declare for each location, from the
+// rules of a to the ones of b
+func diffSchemaRules(a, b []SchemaRule) ConfigDiff {
+	right := make(map[string]SchemaRule, len(b))
+	for _, rule := range b {
+		right[rule.Location] = rule
+	}
+
+	d := ConfigDiff{Added: []ConfigChange{}, Removed: []ConfigChange{}, Changed: []ConfigChange{}}
+	for _, rule := range a {
+		other, ok := right[rule.Location]
+		delete(right, rule.Location)
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, ConfigChange{Path: rule.Location, Old: rule.String()})
+		case rule.String() != other.String():
+			d.Changed = append(d.Changed, ConfigChange{Path: rule.Location, Old: rule.String(), New: other.String()})
+		}
+	}
+	for _, rule := range b {
+		if _, ok := right[rule.Location]; ok {
+			d.Added = append(d.Added, ConfigChange{Path: rule.Location, New: rule.String()})
+		}
+	}
+	return d
+}
+
+func schemaPrintFunc(cmd *cobra.Command, args []string) error {
+	return commandExitError(cmd, schemaPrintFuncErr(cmd, args))
+}
+
+func schemaPrintFuncErr(cmd *cobra.Command, _ []string) error {
+	data, err := indentSchema(rawEmbedSchema, schemaIndent)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
+
+// indentSchema pretty-prints the raw schema with the received indentation
+func indentSchema(rawSchema, indent string) ([]byte, error) {
+	if rawSchema == "" {
+		return nil, errors.New("this binary does not embed a schema. Use krakend schema fetch to download the official one")
+	}
+	buf := new(bytes.Buffer)
+	if err := json.Indent(buf, []byte(rawSchema), "", indent); err != nil {
+		return nil, fmt.Errorf("invalid embedded schema: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func schemaLinkFunc(cmd *cobra.Command, args []string) error {
+	return commandExitError(cmd, schemaLinkFuncErr(cmd, args))
+}
+
+func schemaLinkFuncErr(cmd *cobra.Command, _ []string) error {
+	if cfgFile == "" {
+		return errors.New("please, provide the path to the configuration file with --config or see all the options with --help")
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	if documentFormat(cfgFile, data) != formatJSON {
+		return fmt.Errorf("%s is not a JSON configuration", cfgFile)
+	}
+
+	var linked []byte
+	var schemaURL string
+	if schemaUnlink {
+		linked, err = unlinkSchema(data)
+	} else {
+		if schemaURL, err = onlineSchemaURL(onlineSchemaBaseURL(), schemaVersion); err != nil {
+			return err
+		}
+		linked, err = linkSchema(data, schemaURL)
+	}
+	if err != nil {
+		return fmt.Errorf("updating %s: %w", cfgFile, err)
+	}
+	if bytes.Equal(data, linked) {
+		return nil
+	}
+	if err := writeFilePreservingMode(cfgFile, linked); err != nil {
+		return err
+	}
+
+	if schemaUnlink {
+		cmd.Println(okMsg("Schema removed from " + cfgFile))
+	} else {
+		cmd.Println(okMsg(fmt.Sprintf("Schema of %s set to %s", cfgFile, schemaURL)))
+	}
+	return nil
+}
+
+// linkSchema sets the top-level $schema property of the JSON document, editing it in place
+// so the rest of the document keeps its layout
+func linkSchema(data []byte, schemaURL string) ([]byte, error) {
+	m, err := findSchemaMember(data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	member := `"$schema": ` + string(value)
+
+	var res []byte
+	switch {
+	case m.found:
+		res = append(res, data[:m.start]...)
+		res = append(res, member...)
+		res = append(res, data[m.end:]...)
+	case m.first < 0:
+		res = append(res, data[:m.open]...)
+		res = append(res, member...)
+		res = append(res, data[m.open:]...)
+	default:
+		sep := string(data[m.open:m.first])
+		if sep == "" {
+			sep = " "
+		}
+		res = append(res, data[:m.first]...)
+		res = append(res, member+","+sep...)
+		res = append(res, data[m.first:]...)
+	}
+	return res, nil
+}
+
+// unlinkSchema removes the top-level $schema property of the JSON document, if declared
+func unlinkSchema(data []byte) ([]byte, error) {
+	m, err := findSchemaMember(data)
+	if err != nil || !m.found {
+		return data, err
+	}
+
+	next := skipJSONSeparators(data, m.end)
+	if bytes.IndexByte(data[m.end:next], ',') >= 0 && next < len(data) && data[next] != '}' {
+		// a member follows: remove up to its key, so it takes the place of the removed one
+		return append(append([]byte{}, data[:m.start]...), data[next:]...), nil
+	}
+	// it is the last member: remove the separator from the previous one
+	return append(append([]byte{}, data[:m.prev]...), data[m.end:]...), nil
+}
+
+// schemaMember locates the $schema property of a JSON document
+type schemaMember struct {
+	found bool
+	// start and end delimit the declaration of the property and prev is the end of the
+	// previous member or the opening brace
+	start, end, prev int
+	// open is the offset just after the opening brace and first, the start of the first
+	// member or -1 if the object is empty
+	open, first int
+}
+
+func findSchemaMember(data []byte) (schemaMember, error) {
+	m := schemaMember{first: -1}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return m, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return m, errors.New("the configuration is not a JSON object")
+	}
+	m.open = int(dec.InputOffset())
+
+	for dec.More() {
+		prev := int(dec.InputOffset())
+		start := skipJSONSeparators(data, prev)
+		if m.first < 0 {
+			m.first = start
+		}
+		key, err := dec.Token()
+		if err != nil {
+			return m, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return m, err
+		}
+		if key == "$schema" {
+			m.found, m.start, m.end, m.prev = true, start, int(dec.InputOffset()), prev
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// writeFileAtomic replaces the file with the content, so a failure never leaves it truncated
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import "github.com/santhosh-tekuri/jsonschema/v6"
+
+var (
+	schemaFormats      []*jsonschema.Format
+	schemaVocabularies []*jsonschema.Vocabulary
+)
+
+// RegisterSchemaFormats declares custom formats for the schemas used by the linting, like the
+// durations of an internal schema. Once any format is registered, the formats are asserted by
+// the schemas of every draft, instead of only annotating the values. It is not safe for
+// concurrent use with the checks
+func RegisterSchemaFormats(formats ...*jsonschema.Format) {
+	schemaFormats = append(schemaFormats, formats...)
+}
+
+// RegisterSchemaVocabularies declares vocabularies adding custom keywords to the schemas used
+// by the linting, like the conventions of an organization. They are asserted even when the
+// meta-schema does not list them in its $vocabulary. It is not safe for concurrent use with
+// the checks
+func RegisterSchemaVocabularies(vocabularies ...*jsonschema.Vocabulary) {
+	schemaVocabularies = append(schemaVocabularies, vocabularies...)
+}
+
+// newSchemaCompiler returns a compiler with the registered formats and vocabularies
+func newSchemaCompiler() *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	for _, f := range schemaFormats {
+		compiler.RegisterFormat(f)
+	}
+	if len(schemaFormats) > 0 {
+		compiler.AssertFormat()
+	}
+	for _, v := range schemaVocabularies {
+		compiler.RegisterVocabulary(v)
+	}
+	if len(schemaVocabularies) > 0 {
+		compiler.AssertVocabs()
+	}
+	return compiler
+}
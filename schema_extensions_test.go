@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/message"
+)
+
+const extendedTestSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "timeout": {"type": "string", "format": "krakend-duration"},
+    "name": {"type": "string", "maxWords": 2}
+  }
+}`
+
+// maxWords limits the number of words of a string
+type maxWords int
+
+func (m maxWords) Validate(ctx *jsonschema.ValidatorContext, v interface{}) {
+	if s, ok := v.(string); ok && len(strings.Fields(s)) > int(m) {
+		ctx.AddError(&maxWordsError{max: int(m)})
+	}
+}
+
+type maxWordsError struct {
+	max int
+}
+
+func (*maxWordsError) KeywordPath() []string {
+	return []string{"maxWords"}
+}
+
+func (e *maxWordsError) LocalizedString(*message.Printer) string {
+	return fmt.Sprintf("more than %d words", e.max)
+}
+
+func TestRegisterSchemaExtensions(t *testing.T) {
+	origFormats, origVocabularies := schemaFormats, schemaVocabularies
+	defer func() { schemaFormats, schemaVocabularies = origFormats, origVocabularies }()
+
+	cfg := []byte(`{"timeout": "3 seconds", "name": "a long name"}`)
+	findings, err := ValidateConfig(cfg, []byte(extendedTestSchema))
+	require.NoError(t, err)
+	require.Empty(t, findings)
+
+	RegisterSchemaFormats(&jsonschema.Format{
+		Name: "krakend-duration",
+		Validate: func(v interface{}) error {
+			s, ok := v.(string)
+			if !ok {
+				return nil
+			}
+			_, err := time.ParseDuration(s)
+			return err
+		},
+	})
+
+	meta, err := jsonschema.UnmarshalJSON(strings.NewReader(`{"properties": {"maxWords": {"type": "integer", "minimum": 1}}}`))
+	require.NoError(t, err)
+	metaCompiler := jsonschema.NewCompiler()
+	require.NoError(t, metaCompiler.AddResource("max-words.json", meta))
+	RegisterSchemaVocabularies(&jsonschema.Vocabulary{
+		URL:    "https://example.com/vocab/max-words",
+		Schema: metaCompiler.MustCompile("max-words.json"),
+		Compile: func(_ *jsonschema.CompilerContext, obj map[string]interface{}) (jsonschema.SchemaExt, error) {
+			v, ok := obj["maxWords"]
+			if !ok {
+				return nil, nil
+			}
+			n, err := v.(json.Number).Int64()
+			if err != nil {
+				return nil, err
+			}
+			return maxWords(n), nil
+		},
+	})
+
+	findings, err = ValidateConfig(cfg, []byte(extendedTestSchema))
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	require.Equal(t, "/name", findings[0].Location)
+	require.Contains(t, findings[0].Message, "more than 2 words")
+	require.Equal(t, "/timeout", findings[1].Location)
+	require.Contains(t, findings[1].Message, "krakend-duration")
+
+	findings, err = ValidateConfig([]byte(`{"timeout": "3s", "name": "short"}`), []byte(extendedTestSchema))
+	require.NoError(t, err)
+	require.Empty(t, findings)
+}
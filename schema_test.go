@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retryTransport(t *testing.T) {
+	tests := map[string]struct {
+		statuses []int
+		status   int
+		calls    int32
+	}{
+		"ok":                {statuses: []int{http.StatusOK}, status: http.StatusOK, calls: 1},
+		"not found":         {statuses: []int{http.StatusNotFound}, status: http.StatusNotFound, calls: 1},
+		"recovered":         {statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, status: http.StatusOK, calls: 3},
+		"retries exhausted": {statuses: []int{http.StatusBadGateway}, status: http.StatusBadGateway, calls: 3},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				i := int(atomic.AddInt32(&calls, 1)) - 1
+				if i >= len(tc.statuses) {
+					i = len(tc.statuses) - 1
+				}
+				w.WriteHeader(tc.statuses[i])
+			}))
+			defer s.Close()
+
+			client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, retries: 2, backoff: time.Millisecond}}
+			resp, err := client.Get(s.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tc.status, resp.StatusCode)
+			require.Equal(t, tc.calls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func Test_contextTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := newSchemaHTTPClient(SchemaLoaderOptions{Timeout: time.Minute, Context: ctx})
+	require.NoError(t, err)
+
+	loader := SchemaHttpLoader(*client)
+	_, err = loader.Load(s.URL + "/schema.json")
+	require.NoError(t, err)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = loader.Load(s.URL + "/hang")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_parseSchemaHeaders(t *testing.T) {
+	headers, err := parseSchemaHeaders([]string{"Authorization: Bearer a:b", " X-Api-Key :secret"})
+	require.NoError(t, err)
+	require.Equal(t, "Bearer a:b", headers.Get("Authorization"))
+	require.Equal(t, "secret", headers.Get("X-Api-Key"))
+
+	for _, def := range []string{"no-colon", ": value", "Bad Name: value"} {
+		_, err := parseSchemaHeaders([]string{def})
+		require.Error(t, err, def)
+	}
+}
+
+func Test_fetchSchema(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valid.json":
+			w.Write([]byte(`{"type":"object","properties":{"port":{"type":"integer"}}}`))
+		case "/invalid.json":
+			w.Write([]byte(`{"type":"unknown"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+	opts := SchemaLoaderOptions{Timeout: time.Second, NoCache: true}
+
+	data, err := fetchSchema(s.URL+"/valid.json", opts)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"properties\": {\n    \"port\": {\n      \"type\": \"integer\"\n    }\n  },\n  \"type\": \"object\"\n}\n", string(data))
+
+	_, err = fetchSchema(s.URL+"/invalid.json", opts)
+	require.ErrorContains(t, err, "compiling the schema")
+
+	_, err = fetchSchema(s.URL+"/missing.json", opts)
+	require.ErrorContains(t, err, "returned status code 404")
+}
+
+func Test_schemaDiffFuncErr(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2.6/krakend.json", r.URL.Path)
+		w.Write([]byte(`{"type":"object","required":["version"],"properties":{"port":{"type":"string"},"host":{"type":"array"}}}`))
+	}))
+	defer s.Close()
+
+	origSchema, origBase, origVersion, origNoCache := rawEmbedSchema, schemaBaseURL, schemaVersion, schemaNoCache
+	defer func() {
+		rawEmbedSchema, schemaBaseURL, schemaVersion, schemaNoCache = origSchema, origBase, origVersion, origNoCache
+	}()
+	rawEmbedSchema = `{"type":"object","required":["version"],"properties":{"port":{"type":"integer"},"name":{"type":"string"}}}`
+	schemaBaseURL, schemaVersion, schemaNoCache = s.URL, "2.6", true
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	d, err := schemaDiffFuncErr(cmd, nil)
+	require.NoError(t, err)
+	require.Equal(t, ConfigDiff{
+		Added:   []ConfigChange{{Path: "/host", New: "type array"}},
+		Removed: []ConfigChange{{Path: "/name", Old: "type string"}},
+		Changed: []ConfigChange{{Path: "/port", Old: "type integer", New: "type string"}},
+	}, d)
+	require.Contains(t, out.String(), "1 added, 1 removed, 1 changed")
+
+	// the differences are reported, so only the exit code is left to Execute
+	var exitErr *ExitError
+	require.ErrorAs(t, schemaDiffFunc(cmd, nil), &exitErr)
+	require.Equal(t, &ExitError{Code: ExitCodeFailure}, exitErr)
+
+	rawEmbedSchema = ""
+	require.ErrorAs(t, schemaDiffFunc(cmd, nil), &exitErr)
+	require.Equal(t, ExitCodeFailure, exitErr.Code)
+	require.ErrorContains(t, exitErr.Err, "does not embed a schema")
+}
+
+func Test_indentSchema(t *testing.T) {
+	data, err := indentSchema(`{"type":"object","required":["version"]}`, "\t")
+	require.NoError(t, err)
+	require.Equal(t, "{\n\t\"type\": \"object\",\n\t\"required\": [\n\t\t\"version\"\n\t]\n}\n", string(data))
+
+	_, err = indentSchema("", "\t")
+	require.ErrorContains(t, err, "does not embed a schema")
+
+	_, err = indentSchema("{", "\t")
+	require.ErrorContains(t, err, "invalid embedded schema")
+}
+
+func Test_linkSchema(t *testing.T) {
+	url := "https://www.krakend.io/schema/v2.6/krakend.json"
+	tests := map[string]struct {
+		in, linked, unlinked string
+	}{
+		"indented": {
+			in:       "{\n  \"version\": 3,\n  \"port\": 8080\n}\n",
+			linked:   "{\n  \"$schema\": \"" + url + "\",\n  \"version\": 3,\n  \"port\": 8080\n}\n",
+			unlinked: "{\n  \"version\": 3,\n  \"port\": 8080\n}\n",
+		},
+		"compact": {
+			in:       `{"version":3}`,
+			linked:   `{"$schema": "` + url + `", "version":3}`,
+			unlinked: `{"version":3}`,
+		},
+		"empty": {
+			in:       `{}`,
+			linked:   `{"$schema": "` + url + `"}`,
+			unlinked: `{}`,
+		},
+		"outdated": {
+			in:       "{\n  \"version\": 3,\n  \"$schema\": \"https://www.krakend.io/schema/v2.4/krakend.json\"\n}",
+			linked:   "{\n  \"version\": 3,\n  \"$schema\": \"" + url + "\"\n}",
+			unlinked: "{\n  \"version\": 3\n}",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			linked, err := linkSchema([]byte(tc.in), url)
+			require.NoError(t, err)
+			require.Equal(t, tc.linked, string(linked))
+
+			unlinked, err := unlinkSchema(linked)
+			require.NoError(t, err)
+			require.Equal(t, tc.unlinked, string(unlinked))
+		})
+	}
+
+	_, err := linkSchema([]byte(`[]`), url)
+	require.Error(t, err)
+}
+
+func TestSchemaHttpLoader_gzip(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"type":"object"}`))
+		gz.Close()
+	}))
+	defer s.Close()
+
+	loader := SchemaHttpLoader(http.Client{Timeout: time.Second})
+	doc, err := loader.Load(s.URL)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"type": "object"}, doc)
+}
+
+func Test_progressLoader(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"type":"object"}`))
+	}))
+	defer s.Close()
+
+	var reports int32
+	httpLoader := SchemaHttpLoader(http.Client{Timeout: time.Second})
+	loader := &progressLoader{
+		Loader:   &httpLoader,
+		Interval: 20 * time.Millisecond,
+		Timeout:  time.Second,
+		Progressf: func(format string, _ ...interface{}) {
+			require.Equal(t, "Still downloading %s (%s elapsed, timeout %s)...\n", format)
+			atomic.AddInt32(&reports, 1)
+		},
+	}
+	_, err := loader.Load(s.URL)
+	require.NoError(t, err)
+
+	received := atomic.LoadInt32(&reports)
+	require.Positive(t, received)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, received, atomic.LoadInt32(&reports))
+
+	require.Equal(t, time.Second, progressInterval(5*time.Second))
+	require.Equal(t, 3*time.Second, progressInterval(30*time.Second))
+}
+
+func Test_discoverSchema(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "krakend.json")
+	require.NoError(t, os.WriteFile(cfg, []byte(`{}`), 0o600))
+	require.Empty(t, discoverSchema(cfg, map[string]interface{}{}))
+	require.Empty(t, discoverSchema("stdin", map[string]interface{}{}))
+
+	vendored := filepath.Join(dir, "schema", "krakend.json")
+	require.NoError(t, os.Mkdir(filepath.Dir(vendored), 0o755))
+	require.NoError(t, os.WriteFile(vendored, []byte(`{}`), 0o600))
+	require.Equal(t, vendored, discoverSchema(cfg, map[string]interface{}{}))
+	require.Equal(t, vendored, discoverSchema(cfg, map[string]interface{}{"$schema": "https://www.krakend.io/schema/v2.6/krakend.json"}))
+	require.Equal(t, vendored, discoverSchema(cfg, map[string]interface{}{"$schema": "missing.json"}))
+
+	referenced := filepath.Join(dir, "krakend-2.6.json")
+	require.NoError(t, os.WriteFile(referenced, []byte(`{}`), 0o600))
+	require.Equal(t, referenced, discoverSchema(cfg, map[string]interface{}{"$schema": "./krakend-2.6.json"}))
+	require.Equal(t, referenced, discoverSchema(cfg, map[string]interface{}{"$schema": "file://" + referenced}))
+}
+
+func TestSchemaCacheLoader(t *testing.T) {
+	tests := map[string]struct {
+		ttl        time.Duration
+		refresh    bool
+		expire     bool
+		unwritable bool
+		hits       int32
+		cached     bool
+	}{
+		"cached":         {ttl: time.Hour, hits: 1, cached: true},
+		"expired":        {ttl: time.Hour, expire: true, hits: 2, cached: true},
+		"refresh":        {ttl: time.Hour, refresh: true, hits: 2, cached: true},
+		"unwritable dir": {ttl: time.Hour, unwritable: true, hits: 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var hits int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				_, _ = w.Write([]byte(`{"type": "object"}`))
+			}))
+			defer s.Close()
+
+			dir := filepath.Join(t.TempDir(), "schema")
+			if tc.unwritable {
+				// a regular file can not be the parent of the cache directory
+				parent := filepath.Join(t.TempDir(), "file")
+				require.NoError(t, os.WriteFile(parent, nil, 0o600))
+				dir = filepath.Join(parent, "schema")
+			}
+			httpLoader := SchemaHttpLoader(http.Client{Timeout: time.Second})
+			var logs bytes.Buffer
+			l := &SchemaCacheLoader{
+				Loader:  &httpLoader,
+				Dir:     dir,
+				TTL:     tc.ttl,
+				Refresh: tc.refresh,
+				Logf:    func(format string, a ...interface{}) { fmt.Fprintf(&logs, format, a...) },
+			}
+			url := s.URL + "/krakend.json"
+			path := filepath.Join(dir, schemaCacheKey(url))
+
+			for i := 0; i < 2; i++ {
+				doc, err := l.Load(url)
+				require.NoError(t, err)
+				require.Equal(t, map[string]interface{}{"type": "object"}, doc)
+				if i == 0 && tc.expire {
+					old := time.Now().Add(-2 * tc.ttl)
+					require.NoError(t, os.Chtimes(path, old, old))
+				}
+			}
+			require.Equal(t, tc.hits, atomic.LoadInt32(&hits))
+
+			if !tc.cached {
+				require.NoFileExists(t, path)
+				require.Contains(t, logs.String(), "Unable to cache the schema")
+				return
+			}
+			require.FileExists(t, path)
+		})
+	}
+}
+
+func Test_newSchemaHTTPClient_proxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		// a proxy receives the absolute URL of the target
+		require.Equal(t, "http://schemas.example.com/krakend.json", r.URL.String())
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	t.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+	t.Setenv("HTTPS_PROXY", "http://127.0.0.1:1")
+	t.Setenv("NO_PROXY", "")
+
+	tests := map[string]struct {
+		proxy string
+		env   bool
+	}{
+		"explicit proxy":    {proxy: proxy.URL},
+		"environment proxy": {env: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := newSchemaHTTPClient(SchemaLoaderOptions{Timeout: time.Second, Proxy: tc.proxy})
+			require.NoError(t, err)
+			transport := client.Transport.(*retryTransport).next.(*http.Transport)
+
+			if tc.env {
+				require.Equal(t, reflect.ValueOf(http.ProxyFromEnvironment).Pointer(), reflect.ValueOf(transport.Proxy).Pointer())
+				return
+			}
+
+			resp, err := client.Get("http://schemas.example.com/krakend.json")
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+		})
+	}
+
+	_, err := newSchemaHTTPClient(SchemaLoaderOptions{Timeout: time.Second, Proxy: "proxy:3128"})
+	require.ErrorContains(t, err, "invalid schema proxy URL")
+}
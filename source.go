@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const stdinConfig = "-"
+
+// inlineConfigName names the configuration received as a flag value
+const inlineConfigName = "inline"
+
+// configSource is a configuration to check. Name identifies it in the messages and Path is
+// the file handled to the parser. When the content is not read from a regular file, it is
+// kept in Content and persisted in a temporary file removed by Close
+type configSource struct {
+	Name    string
+	Path    string
+	Content []byte
+	temp    bool
+}
+
+// openConfigSource prepares the configuration file, or the standard input, for the parser. When
+// the format is set and the extension of the file does not match it, the content is copied to
+// a temporary file with the extension of the format, so the parser and the linter use it. The
+// standard input is read up to maxSize bytes, unless it is zero
+func openConfigSource(in io.Reader, file, format string, maxSize int64) (*configSource, error) {
+	if file != stdinConfig {
+		if format == "" || extensionFormat(file) == format {
+			return &configSource{Name: file, Path: file}, nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return newTempConfigSource(file, data, "."+format)
+	}
+
+	data, err := readAllLimited(in, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading stdin: %w", err)
+	}
+	if format == "" {
+		format = documentFormat("", data)
+	}
+	return newTempConfigSource("stdin", data, "."+format)
+}
+
+// isConfigURL tells if the configuration is downloaded from a http or https URL
+func isConfigURL(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
+
+// fetchConfigSource downloads the configuration with the client of the schema loader, so the
+// proxy, headers, retries and timeout apply. The format is detected by the extension of the URL
+// path or the content, unless it is set. The response is read up to maxSize bytes, unless it
+// is zero
+func fetchConfigSource(rawURL, format string, o SchemaLoaderOptions, maxSize int64) (*configSource, error) {
+	client, err := newSchemaHTTPClient(o)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status code %d", rawURL, resp.StatusCode)
+	}
+	data, err := readAllLimited(resp.Body, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", rawURL, err)
+	}
+
+	if format == "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			format = extensionFormat(u.Path)
+		}
+	}
+	if format == "" {
+		format = documentFormat("", data)
+	}
+	return newTempConfigSource(rawURL, data, "."+format)
+}
+
+func newTempConfigSource(name string, data []byte, ext string) (*configSource, error) {
+	f, err := os.CreateTemp("", "krakend-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("storing the content of %s: %w", name, err)
+	}
+	defer f.Close()
+
+	src := &configSource{Name: name, Path: f.Name(), Content: data, temp: true}
+	if _, err := f.Write(data); err != nil {
+		src.Close()
+		return nil, fmt.Errorf("storing the content of %s: %w", name, err)
+	}
+	return src, nil
+}
+
+// Size returns the size in bytes of the raw content of the configuration
+func (s *configSource) Size() (int64, error) {
+	if s.Content != nil {
+		return int64(len(s.Content)), nil
+	}
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// checkConfigSize fails when the size is over the limit, unless the limit is zero
+func checkConfigSize(what string, size, maxSize int64) error {
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("%s is %d bytes, over the limit of %d bytes. Raise it with --max-config-size", what, size, maxSize)
+	}
+	return nil
+}
+
+// readAllLimited reads the content of r, failing when it is over maxSize bytes, unless the
+// limit is zero. At most maxSize+1 bytes are read
+func readAllLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("the content is over the limit of %d bytes. Raise it with --max-config-size", maxSize)
+	}
+	return data, nil
+}
+
+// ReadContent returns the raw content of the configuration
+func (s *configSource) ReadContent() ([]byte, error) {
+	if s.Content != nil {
+		return s.Content, nil
+	}
+	return os.ReadFile(s.Path)
+}
+
+// Error replaces the references to the temporary path in the error message with the name
+// of the source
+func (s *configSource) Error(err error) error {
+	if !s.temp || err == nil {
+		return err
+	}
+	return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), s.Path, s.Name))
+}
+
+func (s *configSource) Close() {
+	if s.temp {
+		_ = os.Remove(s.Path)
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_openConfigSource(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	src, err := openConfigSource(strings.NewReader("unused"), cfg, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, &configSource{Name: cfg, Path: cfg}, src)
+	src.Close()
+	require.FileExists(t, cfg)
+
+	for name, tc := range map[string]struct {
+		content string
+		format  string
+		ext     string
+	}{
+		"json":            {content: `{"version": 3}`, ext: ".json"},
+		"yaml":            {content: "version: 3\n", ext: ".yaml"},
+		"explicit format": {content: `{"version": 3}`, format: formatYAML, ext: ".yaml"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, err := openConfigSource(strings.NewReader(tc.content), stdinConfig, tc.format, 0)
+			require.NoError(t, err)
+			require.Equal(t, "stdin", src.Name)
+			require.Equal(t, tc.ext, filepath.Ext(src.Path))
+
+			data, err := src.ReadContent()
+			require.NoError(t, err)
+			require.Equal(t, tc.content, string(data))
+			b, err := os.ReadFile(src.Path)
+			require.NoError(t, err)
+			require.Equal(t, tc.content, string(b))
+
+			src.Close()
+			require.NoFileExists(t, src.Path)
+		})
+	}
+
+	_, err = openConfigSource(strings.NewReader(`{"version": 3}`), stdinConfig, "", 5)
+	require.ErrorContains(t, err, "reading stdin")
+}
+
+func Test_configSource_Error(t *testing.T) {
+	src, err := openConfigSource(strings.NewReader(`{}`), stdinConfig, "", 0)
+	require.NoError(t, err)
+	defer src.Close()
+
+	require.NoError(t, src.Error(nil))
+	require.EqualError(t, src.Error(errors.New("parsing "+src.Path+": unexpected EOF")), "parsing stdin: unexpected EOF")
+
+	file := &configSource{Name: "krakend.json", Path: "krakend.json"}
+	require.EqualError(t, file.Error(errors.New("parsing krakend.json")), "parsing krakend.json")
+}
+
+func Test_readAllLimited(t *testing.T) {
+	data, err := readAllLimited(strings.NewReader("12345"), 0)
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(data))
+
+	data, err = readAllLimited(strings.NewReader("12345"), 5)
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(data))
+
+	_, err = readAllLimited(strings.NewReader("123456"), 5)
+	require.ErrorContains(t, err, "over the limit of 5 bytes")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// pluginNamespaces are the namespaces loading plugins, declared by name
+var pluginNamespaces = []string{"plugin/http-server", "plugin/http-client", "plugin/req-resp-modifier"}
+
+// ConfigSummary counts the main components of the parsed configuration, so a truncated
+// configuration stands out
+type ConfigSummary struct {
+	Endpoints int `json:"endpoints"`
+	// Backends counts the backends of the endpoints and the async agents
+	Backends    int `json:"backends"`
+	AsyncAgents int `json:"async_agents"`
+	// Plugins are the names of the plugins loaded by the configuration
+	Plugins []string `json:"plugins"`
+}
+
+func newConfigSummary(v config.ServiceConfig) ConfigSummary {
+	s := ConfigSummary{Endpoints: len(v.Endpoints), AsyncAgents: len(v.AsyncAgents), Plugins: []string{}}
+	seen := map[string]bool{}
+	addPlugins := func(cfg config.ExtraConfig) {
+		for _, ns := range pluginNamespaces {
+			m, ok := cfg[ns].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var names []interface{}
+			switch t := m["name"].(type) {
+			case string:
+				names = []interface{}{t}
+			case []interface{}:
+				names = t
+			}
+			for _, n := range names {
+				if name, ok := n.(string); ok && !seen[name] {
+					seen[name] = true
+					s.Plugins = append(s.Plugins, name)
+				}
+			}
+		}
+	}
+	addBackends := func(backends []*config.Backend) {
+		s.Backends += len(backends)
+		for _, b := range backends {
+			addPlugins(b.ExtraConfig)
+		}
+	}
+
+	addPlugins(v.ExtraConfig)
+	for _, e := range v.Endpoints {
+		addPlugins(e.ExtraConfig)
+		addBackends(e.Backend)
+	}
+	for _, a := range v.AsyncAgents {
+		addPlugins(a.ExtraConfig)
+		addBackends(a.Backend)
+	}
+	sort.Strings(s.Plugins)
+	return s
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newConfigSummary(t *testing.T) {
+	v := config.ServiceConfig{
+		ExtraConfig: config.ExtraConfig{"plugin/http-server": map[string]interface{}{"name": []interface{}{"auth", "geo"}}},
+		Endpoints: []*config.EndpointConfig{
+			{Backend: []*config.Backend{
+				{ExtraConfig: config.ExtraConfig{"plugin/http-client": map[string]interface{}{"name": "cache"}}},
+				{},
+			}},
+			{
+				ExtraConfig: config.ExtraConfig{"plugin/req-resp-modifier": map[string]interface{}{"name": []interface{}{"auth"}}},
+				Backend:     []*config.Backend{{}},
+			},
+		},
+		AsyncAgents: []*config.AsyncAgent{{Backend: []*config.Backend{{}}}},
+	}
+	require.Equal(t, ConfigSummary{Endpoints: 2, Backends: 4, AsyncAgents: 1, Plugins: []string{"auth", "cache", "geo"}}, newConfigSummary(v))
+}
+
+func TestCheck_summary(t *testing.T) {
+	cfg := writeTestConfig(t, `{"version": 3}`)
+	p := parserFunc(func(string) (config.ServiceConfig, error) {
+		return config.ServiceConfig{Version: 3, Endpoints: []*config.EndpointConfig{
+			{Endpoint: "/a", Method: "GET", Backend: []*config.Backend{{Host: []string{"http://a"}}}},
+		}}, nil
+	})
+
+	res, err := Check(CheckOptions{ConfigFile: cfg, Parser: p})
+	require.NoError(t, err)
+	require.Nil(t, res.Summary)
+
+	var out bytes.Buffer
+	res, err = Check(CheckOptions{ConfigFile: cfg, Parser: p, Summary: true, Output: &out})
+	require.NoError(t, err)
+	require.Equal(t, &ConfigSummary{Endpoints: 1, Backends: 1, Plugins: []string{}}, res.Summary)
+	require.Contains(t, out.String(), "1 endpoint(s), 1 backend(s), 0 async agent(s) and 0 plugin(s)")
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateDirs are the directories used by the flexible configuration to render the
+// configuration template. They default to the FC_SETTINGS, FC_PARTIALS and FC_TEMPLATES
+// env vars
+type TemplateDirs struct {
+	Settings  string
+	Partials  string
+	Templates string
+	// IncludeRoot, when set, rejects the configuration, the directories and the includes
+	// resolving outside it. It is enforced by the render of the check, as the parser of the
+	// configuration does not know about it, so it does not restrict the run command
+	IncludeRoot string
+}
+
+// flexibleConfigDirs maps the env vars of the flexible configuration directories to their
+// conventional names
+var flexibleConfigDirs = []struct{ env, dir string }{
+	{"FC_SETTINGS", "settings"},
+	{"FC_PARTIALS", "partials"},
+	{"FC_TEMPLATES", "templates"},
+}
+
+// applyConfigDir makes the flexible configuration resolve its directories from the base dir:
+// the relative paths in the env vars are resolved against it and, when a var is not set, the
+// conventional subdirectory is used if it exists
+func applyConfigDir(base string) error {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(base); err != nil || !info.IsDir() {
+		return fmt.Errorf("the config dir %s is not a directory", base)
+	}
+
+	for _, fc := range flexibleConfigDirs {
+		v := os.Getenv(fc.env)
+		switch {
+		case v == "":
+			dir := filepath.Join(base, fc.dir)
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				continue
+			}
+			v = dir
+		case filepath.IsAbs(v):
+			continue
+		default:
+			v = filepath.Join(base, v)
+		}
+		if err := os.Setenv(fc.env, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func templateDirsFromEnv() TemplateDirs {
+	return TemplateDirs{
+		Settings:  os.Getenv("FC_SETTINGS"),
+		Partials:  os.Getenv("FC_PARTIALS"),
+		Templates: os.Getenv("FC_TEMPLATES"),
+	}
+}
+
+// TemplateError is a failure found while checking the templates
+type TemplateError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+var (
+	undefinedFuncPattern  = regexp.MustCompile(`function "([^"]+)" not defined`)
+	templateErrorPattern  = regexp.MustCompile(`^template: ([^:]+):(\d+)(?::(\d+))?: (.*)$`)
+	maxUndefinedFunctions = 256
+)
+
+// checkTemplates parses the configuration template and the shared templates and renders
+// the configuration with the settings, in order to detect the syntax errors and the references
+// to undefined settings. The functions not provided by this package (like the ones of the
+// flexible configuration) are replaced by stubs returning nil
+func checkTemplates(name string, content []byte, dirs TemplateDirs) []TemplateError {
+	var root string
+	if dirs.IncludeRoot != "" {
+		var errs []TemplateError
+		root, errs = resolveIncludeRoot(name, dirs)
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+
+	settings, err := loadTemplateSettings(dirs.Settings)
+	if err != nil {
+		return []TemplateError{{File: dirs.Settings, Message: err.Error()}}
+	}
+
+	tmpl := template.New(filepath.Base(name)).Option("missingkey=error").Funcs(template.FuncMap{
+		"marshal": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"include": func(partial string) (string, error) {
+			path := filepath.Join(dirs.Partials, partial)
+			if root != "" {
+				if resolved, ok := insideRoot(root, path); !ok {
+					return "", &includeRootError{partial: partial, resolved: resolved, root: root}
+				}
+			}
+			b, err := os.ReadFile(path)
+			return string(b), err
+		},
+		"env": os.Getenv,
+	})
+
+	files := map[string]string{filepath.Base(name): name}
+	var errs []TemplateError
+	if dirs.Templates != "" {
+		shared, err := filepath.Glob(filepath.Join(dirs.Templates, "*.tmpl"))
+		if err != nil {
+			return []TemplateError{{File: dirs.Templates, Message: err.Error()}}
+		}
+		for _, f := range shared {
+			files[filepath.Base(f)] = f
+			b, err := os.ReadFile(f)
+			if err == nil {
+				err = parseTemplate(tmpl.New(filepath.Base(f)), string(b))
+			}
+			if err != nil {
+				errs = append(errs, newTemplateError(files, f, err))
+			}
+		}
+	}
+
+	if err := parseTemplate(tmpl, string(content)); err != nil {
+		return append(errs, newTemplateError(files, name, err))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if err := tmpl.Execute(io.Discard, settings); err != nil && !stubTemplateError(err) {
+		te := newTemplateError(files, name, err)
+		var rootErr *includeRootError
+		if errors.As(err, &rootErr) {
+			// the chain goes from the configuration to the partial, through the shared template
+			// including it
+			chain := []string{name}
+			if te.File != name {
+				chain = append(chain, te.File)
+			}
+			te.Message = rootErr.Error() + ". Include chain: " + strings.Join(append(chain, rootErr.partial), " -> ")
+		}
+		return []TemplateError{te}
+	}
+	return nil
+}
+
+// includeRootError is returned by the include of a partial resolving outside the include root
+type includeRootError struct {
+	partial, resolved, root string
+}
+
+func (e *includeRootError) Error() string {
+	return fmt.Sprintf("the partial %q resolves to %s, outside the include root %s", e.partial, e.resolved, e.root)
+}
+
+// resolveIncludeRoot resolves the include root and checks the configuration and the directories
+// of the flexible configuration are inside it
+func resolveIncludeRoot(name string, dirs TemplateDirs) (string, []TemplateError) {
+	root, err := filepath.Abs(dirs.IncludeRoot)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", []TemplateError{{File: dirs.IncludeRoot, Message: "invalid include root: " + err.Error()}}
+	}
+
+	var errs []TemplateError
+	for _, p := range []struct{ desc, path string }{
+		{"the configuration", name},
+		{"the settings dir", dirs.Settings},
+		{"the partials dir", dirs.Partials},
+		{"the templates dir", dirs.Templates},
+	} {
+		if p.path == "" {
+			continue
+		}
+		if _, err := os.Stat(p.path); err != nil && p.path == name {
+			// the configuration read from stdin has no path
+			continue
+		}
+		if resolved, ok := insideRoot(root, p.path); !ok {
+			errs = append(errs, TemplateError{File: p.path, Message: fmt.Sprintf("%s resolves to %s, outside the include root %s", p.desc, resolved, root)})
+		}
+	}
+	return root, errs
+}
+
+// insideRoot resolves the path, following its symlinks when it exists, and tells if it is
+// inside the root
+func insideRoot(root, path string) (string, bool) {
+	resolved, err := filepath.Abs(path)
+	if err != nil {
+		return path, false
+	}
+	if r, err := filepath.EvalSymlinks(resolved); err == nil {
+		resolved = r
+	}
+	rel, err := filepath.Rel(root, resolved)
+	return resolved, err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// stubTemplateError tells if the execution failed while accessing the nil values returned by
+// the stubs, so the failure is not a problem of the template
+func stubTemplateError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nil data; no entry for key") || strings.Contains(msg, "nil pointer evaluating")
+}
+
+// parseTemplate parses the text, registering a stub for every undefined function
+func parseTemplate(tmpl *template.Template, text string) error {
+	for i := 0; i < maxUndefinedFunctions; i++ {
+		_, err := tmpl.Parse(text)
+		if err == nil {
+			return nil
+		}
+		m := undefinedFuncPattern.FindStringSubmatch(err.Error())
+		if m == nil {
+			return err
+		}
+		tmpl.Funcs(template.FuncMap{m[1]: func(...interface{}) interface{} { return nil }})
+	}
+	return fmt.Errorf("too many undefined functions")
+}
+
+// loadTemplateSettings decodes the JSON files of the settings dir, using their names
+// (without extension) as keys, like the flexible configuration does
+func loadTemplateSettings(dir string) (map[string]interface{}, error) {
+	settings := map[string]interface{}{}
+	if dir == "" {
+		return settings, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, fmt.Errorf("decoding the settings file %s: %w", f, err)
+		}
+		settings[strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))] = v
+	}
+	return settings, nil
+}
+
+// newTemplateError extracts the position of the failure from the message of the template error.
+// The templates are named after the base name of their file, so the files map resolves the
+// names back to their paths
+func newTemplateError(files map[string]string, path string, err error) TemplateError {
+	te := TemplateError{File: path, Message: err.Error()}
+	m := templateErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return te
+	}
+	if f, ok := files[m[1]]; ok {
+		te.File = f
+	}
+	te.Line, _ = strconv.Atoi(m[2])
+	te.Column, _ = strconv.Atoi(m[3])
+	te.Message = m[4]
+	return te
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkTemplates(t *testing.T) {
+	dir := t.TempDir()
+	dirs := TemplateDirs{
+		Settings:  filepath.Join(dir, "settings"),
+		Partials:  filepath.Join(dir, "partials"),
+		Templates: filepath.Join(dir, "templates"),
+	}
+	for _, d := range []string{dirs.Settings, dirs.Partials, dirs.Templates} {
+		require.NoError(t, os.Mkdir(d, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Settings, "service.json"), []byte(`{"port": 8080}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Partials, "timeout.json"), []byte(`"timeout": "3s",`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Templates, "endpoint.tmpl"), []byte(`{"endpoint": "/{{ .name }}"}`), 0o600))
+
+	valid := []byte(`{
+  "version": 3,
+  {{ include "timeout.json" }}
+  "port": {{ .service.port }},
+  "endpoints": [{{ template "endpoint.tmpl" (dict "name" "foo") }}]
+}`)
+	require.Empty(t, checkTemplates("krakend.tmpl", valid, dirs))
+
+	undefined := []byte("{\n  \"port\": {{ .service.address }}\n}")
+	errs := checkTemplates("krakend.tmpl", undefined, dirs)
+	require.Len(t, errs, 1)
+	require.Equal(t, "krakend.tmpl", errs[0].File)
+	require.Equal(t, 2, errs[0].Line)
+	require.Contains(t, errs[0].Message, `map has no entry for key "address"`)
+
+	syntax := []byte("{\n  \"port\": {{ .service.port }\n}")
+	errs = checkTemplates("krakend.tmpl", syntax, dirs)
+	require.Len(t, errs, 1)
+	require.Equal(t, 2, errs[0].Line)
+
+	missingPartial := []byte(`{ {{ include "missing.json" }} }`)
+	require.Len(t, checkTemplates("krakend.tmpl", missingPartial, dirs), 1)
+}
+
+func Test_checkTemplates_includeRoot(t *testing.T) {
+	root := t.TempDir()
+	dirs := TemplateDirs{
+		Partials:    filepath.Join(root, "partials"),
+		Templates:   filepath.Join(root, "templates"),
+		IncludeRoot: root,
+	}
+	for _, d := range []string{dirs.Partials, dirs.Templates} {
+		require.NoError(t, os.Mkdir(d, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Partials, "timeout.json"), []byte(`"timeout": "3s",`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(root), "secret.json"), []byte(`"secret": true,`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dirs.Templates, "extra.tmpl"), []byte(`{{ define "extra" }}{{ include "../../secret.json" }}{{ end }}`), 0o600))
+	cfg := filepath.Join(root, "krakend.tmpl")
+
+	require.Empty(t, checkTemplates(cfg, []byte(`{ {{ include "timeout.json" }} "version": 3 }`), dirs))
+
+	errs := checkTemplates(cfg, []byte(`{ {{ include "../../secret.json" }} "version": 3 }`), dirs)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Message, "outside the include root")
+	require.Contains(t, errs[0].Message, "Include chain: "+cfg+" -> ../../secret.json")
+
+	errs = checkTemplates(cfg, []byte(`{ {{ template "extra" }} "version": 3 }`), dirs)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Message, "Include chain: "+cfg+" -> "+filepath.Join(dirs.Templates, "extra.tmpl")+" -> ../../secret.json")
+
+	outside := dirs
+	outside.Settings = filepath.Dir(root)
+	errs = checkTemplates(cfg, []byte(`{}`), outside)
+	require.Len(t, errs, 1)
+	require.Equal(t, filepath.Dir(root), errs[0].File)
+	require.Contains(t, errs[0].Message, "the settings dir resolves to")
+}
+
+func Test_applyConfigDir(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(base, "settings"), 0o755))
+
+	t.Setenv("FC_SETTINGS", "")
+	t.Setenv("FC_PARTIALS", "config/partials")
+	t.Setenv("FC_TEMPLATES", "/abs/templates")
+
+	require.NoError(t, applyConfigDir(base))
+	require.Equal(t, filepath.Join(base, "settings"), os.Getenv("FC_SETTINGS"))
+	require.Equal(t, filepath.Join(base, "config/partials"), os.Getenv("FC_PARTIALS"))
+	require.Equal(t, "/abs/templates", os.Getenv("FC_TEMPLATES"))
+
+	require.Error(t, applyConfigDir(filepath.Join(base, "missing")))
+}
+
+func Test_rootPreRun_configDir(t *testing.T) {
+	origDir := configDir
+	defer func() { configDir = origDir }()
+	configDir = filepath.Join(t.TempDir(), "missing")
+
+	err := rootPreRun(&cobra.Command{Use: "test"}, nil)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, ExitCodeUsage, exitErr.Code)
+	require.ErrorContains(t, err, "is not a directory")
+}
+
+func TestCheck_includeRootContinueOnError(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(root), "secret.json"), []byte(`"secret": true,`), 0o600))
+	cfg := filepath.Join(root, "krakend.tmpl")
+	require.NoError(t, os.WriteFile(cfg, []byte(`{ {{ include "../secret.json" }} "version": 3 }`), 0o600))
+
+	parsed := false
+	res, err := Check(CheckOptions{
+		ConfigFile:      cfg,
+		ContinueOnError: true,
+		TemplateDirs:    TemplateDirs{Partials: root, IncludeRoot: root},
+		Parser: parserFunc(func(string) (config.ServiceConfig, error) {
+			parsed = true
+			return config.ServiceConfig{}, nil
+		}),
+	})
+	require.NoError(t, err)
+	require.False(t, parsed, "the parser must not read the includes outside the root")
+	require.NotEmpty(t, res.Errors)
+	require.Contains(t, res.Errors[0].Message, "outside the include root")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// timeoutIssue is a timeout larger than the one of the scope enclosing it, which expires first
+// and makes the larger one useless
+type timeoutIssue struct {
+	Location string
+	Timeout  time.Duration
+	// Limit is the timeout of the enclosing scope, declared at LimitLocation
+	Limit         time.Duration
+	LimitLocation string
+}
+
+// timeoutIssues compares the timeouts of the endpoints with the one of the service, and the
+// timeouts the backends declare in the document of the configuration with the one of their
+// endpoint. The parser replaces the timeout of the backends with the one of the endpoint, so
+// the declared ones are read from the document. The unset timeouts are ignored
+func timeoutIssues(v config.ServiceConfig, doc interface{}) []timeoutIssue {
+	var issues []timeoutIssue
+	check := func(location string, timeout time.Duration, limitLocation string, limit time.Duration) {
+		if limit > 0 && timeout > limit {
+			issues = append(issues, timeoutIssue{Location: location, Timeout: timeout, Limit: limit, LimitLocation: limitLocation})
+		}
+	}
+
+	for i, e := range v.Endpoints {
+		pointer := "/endpoints/" + strconv.Itoa(i)
+		check(pointer+"/timeout", e.Timeout, "/timeout", v.Timeout)
+		for j := range e.Backend {
+			if timeout, ok := declaredBackendTimeout(doc, i, j); ok {
+				check(pointer+"/backend/"+strconv.Itoa(j)+"/timeout", timeout, pointer+"/timeout", e.Timeout)
+			}
+		}
+	}
+	return issues
+}
+
+// declaredBackendTimeout returns the timeout of the backend of the endpoint in the document,
+// written as a duration or as a number of nanoseconds, like the parser accepts
+func declaredBackendTimeout(doc interface{}, endpoint, backend int) (time.Duration, bool) {
+	root, _ := doc.(map[string]interface{})
+	endpoints, _ := root["endpoints"].([]interface{})
+	if endpoint >= len(endpoints) {
+		return 0, false
+	}
+	e, _ := endpoints[endpoint].(map[string]interface{})
+	backends, _ := e["backend"].([]interface{})
+	if backend >= len(backends) {
+		return 0, false
+	}
+	b, _ := backends[backend].(map[string]interface{})
+	switch t := b["timeout"].(type) {
+	case string:
+		d, err := time.ParseDuration(t)
+		return d, err == nil
+	case float64:
+		return time.Duration(t), true
+	}
+	return 0, false
+}
+
+func (i timeoutIssue) String() string {
+	return fmt.Sprintf("%s (%s) is larger than %s (%s)", i.Location, i.Timeout, i.LimitLocation, i.Limit)
+}
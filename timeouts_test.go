@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_timeoutIssues(t *testing.T) {
+	v := config.ServiceConfig{
+		Timeout: 2 * time.Second,
+		Endpoints: []*config.EndpointConfig{
+			{Timeout: time.Second, Backend: []*config.Backend{{Timeout: time.Second}}},
+			{Timeout: 3 * time.Second, Backend: []*config.Backend{{Timeout: 3 * time.Second}, {Timeout: 3 * time.Second}, {Timeout: 3 * time.Second}}},
+		},
+		AsyncAgents: []*config.AsyncAgent{{Consumer: config.Consumer{Timeout: 10 * time.Second}}},
+	}
+	// the parser sets the timeouts of the backends to the ones of their endpoints
+	doc := map[string]interface{}{"endpoints": []interface{}{
+		map[string]interface{}{"backend": []interface{}{map[string]interface{}{"timeout": "500ms"}}},
+		map[string]interface{}{"backend": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"timeout": "5s"},
+			map[string]interface{}{"timeout": float64(4 * time.Second)},
+		}},
+	}}
+	require.Equal(t, []timeoutIssue{
+		{Location: "/endpoints/1/timeout", Timeout: 3 * time.Second, Limit: 2 * time.Second, LimitLocation: "/timeout"},
+		{Location: "/endpoints/1/backend/1/timeout", Timeout: 5 * time.Second, Limit: 3 * time.Second, LimitLocation: "/endpoints/1/timeout"},
+		{Location: "/endpoints/1/backend/2/timeout", Timeout: 4 * time.Second, Limit: 3 * time.Second, LimitLocation: "/endpoints/1/timeout"},
+	}, timeoutIssues(v, doc))
+	require.Equal(t, "/endpoints/1/timeout (3s) is larger than /timeout (2s)", timeoutIssues(v, doc)[0].String())
+
+	require.Len(t, timeoutIssues(v, nil), 1)
+	require.Empty(t, timeoutIssues(config.ServiceConfig{Endpoints: []*config.EndpointConfig{{Timeout: time.Minute}}}, nil))
+}
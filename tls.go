@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+// tlsIssue describes a key pair of the TLS configuration of the service that the router would
+// fail to load
+type tlsIssue struct {
+	Location string
+	Err      error
+}
+
+// tlsIssues checks that the files of every key pair declared by the enabled TLS configuration
+// exist and load as a certificate. The relative paths are resolved against the working
+// directory, like the router does
+func tlsIssues(cfg *config.TLS) []tlsIssue {
+	if cfg == nil || cfg.IsDisabled {
+		return nil
+	}
+	var issues []tlsIssue
+	if cfg.PublicKey != "" || cfg.PrivateKey != "" {
+		if err := checkKeyPair(cfg.PublicKey, cfg.PrivateKey); err != nil {
+			issues = append(issues, tlsIssue{Location: "/tls", Err: err})
+		}
+	}
+	for i, k := range cfg.Keys {
+		if err := checkKeyPair(k.PublicKey, k.PrivateKey); err != nil {
+			issues = append(issues, tlsIssue{Location: "/tls/keys/" + strconv.Itoa(i), Err: err})
+		}
+	}
+	return issues
+}
+
+func checkKeyPair(publicKey, privateKey string) error {
+	for _, f := range []struct{ name, path string }{{"public_key", publicKey}, {"private_key", privateKey}} {
+		if f.path == "" {
+			return fmt.Errorf("the %s is not declared", f.name)
+		}
+		info, err := os.Stat(f.path)
+		if err != nil {
+			return fmt.Errorf("the %s file can not be read: %w", f.name, err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("the %s %s is not a file", f.name, f.path)
+		}
+	}
+	if _, err := tls.LoadX509KeyPair(publicKey, privateKey); err != nil {
+		return fmt.Errorf("the key pair %s and %s can not be loaded: %w", publicKey, privateKey, err)
+	}
+	return nil
+}
+
+func (i tlsIssue) String() string {
+	return i.Location + ": " + i.Err.Error()
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luraproject/lura/v2/config"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestKeyPair writes a self-signed certificate and its key, returning their paths
+func writeTestKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+	return certFile, keyFile
+}
+
+func Test_tlsIssues(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	require.Empty(t, tlsIssues(nil))
+	require.Empty(t, tlsIssues(&config.TLS{PublicKey: certFile, PrivateKey: keyFile}))
+	require.Empty(t, tlsIssues(&config.TLS{IsDisabled: true, PublicKey: missing, PrivateKey: missing}))
+
+	issues := tlsIssues(&config.TLS{
+		PublicKey:  certFile,
+		PrivateKey: missing,
+		Keys: []config.TLSKeyPair{
+			{PublicKey: certFile, PrivateKey: keyFile},
+			{PublicKey: keyFile, PrivateKey: certFile},
+			{PublicKey: certFile},
+		},
+	})
+	require.Len(t, issues, 3)
+	require.Equal(t, "/tls", issues[0].Location)
+	require.ErrorIs(t, issues[0].Err, os.ErrNotExist)
+	require.Contains(t, issues[0].String(), "/tls: the private_key file can not be read")
+	require.Equal(t, "/tls/keys/1", issues[1].Location)
+	require.Contains(t, issues[1].Err.Error(), "can not be loaded")
+	require.Equal(t, "/tls/keys/2: the private_key is not declared", issues[2].String())
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// watchDebounce groups the changes of a save, like the writes of an editor replacing a file,
+// in a single check
+var watchDebounce = 200 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// watchChecks runs the checks and runs them again every time any of the watched files, or the
+// files in the watched dirs and their subdirs, change. It stops when the context is done
+func watchChecks(ctx context.Context, cmd *cobra.Command, paths []string, run func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return checkUsageError(cmd, "ERROR watching the configuration:", err)
+	}
+	defer watcher.Close()
+
+	// the dirs of the files are watched, so the files replaced on save are still tracked
+	files := map[string]struct{}{}
+	var dirs []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return checkUsageError(cmd, "ERROR watching the configuration:", err)
+		}
+		if fi, err := os.Stat(abs); err == nil && fi.IsDir() {
+			dirs = append(dirs, abs)
+			if err := watchTree(watcher, abs); err != nil {
+				return checkUsageError(cmd, "ERROR watching the configuration:", fmt.Errorf("watching %s: %w", p, err))
+			}
+			continue
+		}
+		files[abs] = struct{}{}
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			return checkUsageError(cmd, "ERROR watching the configuration:", fmt.Errorf("watching %s: %w", p, err))
+		}
+	}
+	watched := func(name string) bool {
+		if _, ok := files[name]; ok {
+			return true
+		}
+		for _, d := range dirs {
+			if name == d || strings.HasPrefix(name, d+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	rerun := func() {
+		if IsTTY {
+			fmt.Fprint(cmd.ErrOrStderr(), clearScreen)
+		}
+		run()
+		cmd.PrintErrln("Watching for changes. Press Ctrl+C to exit")
+	}
+	rerun()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(ev.Name)
+			if ev.Op == fsnotify.Chmod || !watched(name) {
+				continue
+			}
+			// the watches are not recursive, so the new subdirs are added as they appear
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+					if err := watchTree(watcher, name); err != nil {
+						cmd.PrintErrln(errorMsg("ERROR watching the configuration:") + fmt.Sprintf("\twatching %s: %s\n", name, err.Error()))
+					}
+				}
+			}
+			debounce = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			cmd.PrintErrln(errorMsg("ERROR watching the configuration:") + fmt.Sprintf("\t%s\n", err.Error()))
+		case <-debounce:
+			debounce = nil
+			rerun()
+		}
+	}
+}
+
+// watchTree adds the dir and all its subdirs to the watcher
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// checkWatchedPaths returns the local files and dirs the checks of the files depend on
+func checkWatchedPaths(files []string) []string {
+	paths := append([]string{}, files...)
+	dirs := checkTemplateDirs()
+	for _, d := range []string{dirs.Settings, dirs.Partials, dirs.Templates} {
+		if d != "" {
+			paths = append(paths, d)
+		}
+	}
+	for _, s := range lintCustomSchemaPaths {
+		if !isSchemaURL(s) {
+			paths = append(paths, s)
+		}
+	}
+	if lintIgnoreFile != "" {
+		paths = append(paths, lintIgnoreFile)
+	}
+	return paths
+}
+
+func isStdinUsed(paths []string) bool {
+	for _, p := range paths {
+		if p == stdinConfig {
+			return true
+		}
+	}
+	return false
+}
+
+func isConfigURLUsed(paths []string) bool {
+	for _, p := range paths {
+		if isConfigURL(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_watchChecks(t *testing.T) {
+	origDebounce := watchDebounce
+	defer func() { watchDebounce = origDebounce }()
+	watchDebounce = 20 * time.Millisecond
+
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "krakend.json")
+	partials := filepath.Join(dir, "partials")
+	require.NoError(t, os.WriteFile(cfg, []byte(`{"version": 3}`), 0o600))
+	require.NoError(t, os.Mkdir(partials, 0o755))
+
+	var runs int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		cmd := &cobra.Command{}
+		cmd.SetErr(io.Discard)
+		done <- watchChecks(ctx, cmd, []string{cfg, partials}, func() { atomic.AddInt32(&runs, 1) })
+	}()
+
+	waitRuns := func(expected int32) {
+		t.Helper()
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == expected }, 2*time.Second, 10*time.Millisecond)
+	}
+	waitRuns(1)
+
+	// the writes of a save are checked once
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(cfg, []byte(`{"version": 3, "name": "test"}`), 0o600))
+	}
+	waitRuns(2)
+
+	require.NoError(t, os.WriteFile(filepath.Join(partials, "timeout.json"), []byte(`"timeout": "3s",`), 0o600))
+	waitRuns(3)
+
+	// the nested dirs are watched, including the ones created after starting
+	nested := filepath.Join(partials, "backends")
+	require.NoError(t, os.Mkdir(nested, 0o755))
+	waitRuns(4)
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "host.json"), []byte(`"host": ["http://a"],`), 0o600))
+	waitRuns(5)
+
+	// the other files of the dir are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(5), atomic.LoadInt32(&runs))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func Test_watchChecks_nestedPartial(t *testing.T) {
+	origDebounce := watchDebounce
+	defer func() { watchDebounce = origDebounce }()
+	watchDebounce = 20 * time.Millisecond
+
+	partials := t.TempDir()
+	nested := filepath.Join(partials, "endpoints", "users")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	partial := filepath.Join(nested, "backend.json")
+	require.NoError(t, os.WriteFile(partial, []byte(`"host": ["http://a"],`), 0o600))
+
+	var runs int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		cmd := &cobra.Command{}
+		cmd.SetErr(io.Discard)
+		done <- watchChecks(ctx, cmd, []string{partials}, func() { atomic.AddInt32(&runs, 1) })
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(partial, []byte(`"host": ["http://b"],`), 0o600))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}